@@ -0,0 +1,75 @@
+// Shows the running site-wide announcements at the bottom of every page.
+// Dismissals are remembered in the browser, and on the server for logged-in users.
+(function () {
+    var container = document.getElementById("announcements");
+    if (!container || !window.fetch) {
+        return;
+    }
+
+    var storageKey = "dismissedAnnouncements";
+    var dismissed = [];
+    try {
+        dismissed = JSON.parse(localStorage.getItem(storageKey)) || [];
+    } catch (e) {
+        dismissed = [];
+    }
+
+    function dismiss(announcement, element) {
+        element.remove();
+        dismissed.push(announcement.id);
+        try {
+            localStorage.setItem(storageKey, JSON.stringify(dismissed));
+        } catch (e) {
+            // Private windows may refuse storage; the announcement comes back on the next page.
+        }
+        // Only logged-in users have the notifications link; visitors keep dismissals in the browser.
+        if (document.getElementById("notifications-link")) {
+            fetch("/announcements/dismiss", {
+                method: "POST",
+                body: new URLSearchParams({ announcement_id: announcement.id })
+            }).catch(function () {});
+        }
+    }
+
+    fetch("/announcements", { headers: { "Accept": "application/json" } })
+        .then(function (response) {
+            if (!response.ok) {
+                throw new Error("announcements failed");
+            }
+            return response.json();
+        })
+        .then(function (announcements) {
+            announcements.forEach(function (announcement) {
+                if (dismissed.indexOf(announcement.id) !== -1) {
+                    return;
+                }
+                var element = document.createElement("div");
+                element.className = "announcement";
+
+                var message = document.createElement("span");
+                message.textContent = "📢 " + announcement.message;
+                element.appendChild(message);
+
+                if (announcement.link) {
+                    var link = document.createElement("a");
+                    link.href = announcement.link;
+                    link.textContent = "Read more";
+                    element.appendChild(link);
+                }
+
+                var close = document.createElement("button");
+                close.type = "button";
+                close.title = "Dismiss";
+                close.textContent = "×";
+                close.addEventListener("click", function () {
+                    dismiss(announcement, element);
+                });
+                element.appendChild(close);
+
+                container.appendChild(element);
+            });
+        })
+        .catch(function () {
+            // Announcements are optional; the page works without them.
+        });
+})();
@@ -0,0 +1,70 @@
+// Saves the new post form as a draft a few seconds after the author stops typing,
+// so a long post survives a crashed browser. Publishing the post discards the draft.
+(function () {
+    var form = document.querySelector("form.new-post-form");
+    var draftID = document.getElementById("draft_id");
+    var status = document.getElementById("draft-status");
+    if (!form || !draftID || !window.fetch) {
+        return;
+    }
+
+    var delay = 3000;
+    var timer = null;
+    var saving = false;
+
+    function save() {
+        timer = null;
+        var title = form.querySelector("[name=title]").value.trim();
+        var body = form.querySelector("[name=body]").value.trim();
+        if (!title && !body) {
+            return;
+        }
+        // Wait for the previous save, so a new draft is not created twice.
+        if (saving) {
+            schedule();
+            return;
+        }
+        saving = true;
+        fetch("/drafts/save", {
+            method: "POST",
+            headers: { "Accept": "application/json" },
+            body: new URLSearchParams(new FormData(form))
+        })
+            .then(function (response) {
+                if (!response.ok) {
+                    throw new Error("draft not saved");
+                }
+                return response.json();
+            })
+            .then(function (result) {
+                draftID.value = result.id;
+                if (status) {
+                    status.textContent = "Draft saved at " + result.saved_at;
+                }
+            })
+            .catch(function () {
+                if (status) {
+                    status.textContent = "Draft could not be saved";
+                }
+            })
+            .then(function () {
+                saving = false;
+            });
+    }
+
+    function schedule() {
+        if (timer) {
+            clearTimeout(timer);
+        }
+        timer = setTimeout(save, delay);
+    }
+
+    form.addEventListener("input", schedule);
+    form.addEventListener("change", schedule);
+    form.addEventListener("submit", function () {
+        if (timer) {
+            clearTimeout(timer);
+            timer = null;
+        }
+    });
+})();
@@ -0,0 +1,70 @@
+// Live updates over Server-Sent Events.
+// EventSource reconnects on its own and resends the last received event ID,
+// so the server can replay anything that was missed while disconnected.
+(function () {
+    // Count notifications that arrive while the page is open and show them in the header.
+    // New private messages are counted the same way on the "Messages" link.
+    var notificationsLink = document.getElementById("notifications-link");
+    var messagesLink = document.getElementById("messages-link");
+    if (notificationsLink) {
+        var unseen = 0;
+        var unseenMessages = 0;
+        var userEvents = new EventSource("/events/user");
+        userEvents.addEventListener("notification", function () {
+            unseen++;
+            notificationsLink.textContent = "Notifications (" + unseen + ")";
+        });
+        userEvents.addEventListener("message", function () {
+            unseenMessages++;
+            if (messagesLink) {
+                messagesLink.textContent = "Messages (" + unseenMessages + ")";
+            }
+        });
+    }
+
+    // On a post page, append new comments and refresh reaction counts in place.
+    var postContainer = document.querySelector("[data-post-id]");
+    if (!postContainer) {
+        return;
+    }
+    var postID = postContainer.getAttribute("data-post-id");
+    var postEvents = new EventSource("/events/post/" + postID);
+
+    postEvents.addEventListener("comment", function (e) {
+        var comment = JSON.parse(e.data);
+        // Skip comments that are already rendered (e.g. after a replay).
+        if (document.getElementById("comment-" + comment.id)) {
+            return;
+        }
+        var list = document.getElementById("comments");
+        var empty = document.getElementById("no-comments");
+        if (empty) {
+            empty.remove();
+        }
+
+        // The server sends the comment already rendered, with mention links and reaction buttons.
+        var fragment = document.createElement("template");
+        fragment.innerHTML = comment.html.trim();
+        var div = fragment.content.firstElementChild;
+        // Visitors see the reaction buttons disabled, as on the rest of the page.
+        if (!notificationsLink) {
+            div.querySelectorAll("form.reaction-form button").forEach(function (button) {
+                button.disabled = true;
+            });
+        }
+
+        // Newest comments are shown first.
+        list.insertBefore(div, list.firstChild);
+    });
+
+    postEvents.addEventListener("reaction", function (e) {
+        var counts = JSON.parse(e.data);
+        var prefix = counts.target_type + "-" + counts.target_id;
+        Object.keys(counts.counts).forEach(function (reaction) {
+            var count = document.getElementById(prefix + "-reaction-" + reaction);
+            if (count) {
+                count.textContent = counts.counts[reaction];
+            }
+        });
+    });
+})();
@@ -0,0 +1,54 @@
+// Autocomplete for @mentions in textareas marked with the "data-mentions" attribute.
+(function () {
+    document.querySelectorAll("textarea[data-mentions]").forEach(function (textarea) {
+        // The suggestion list is positioned right below the textarea.
+        var list = document.createElement("ul");
+        list.className = "mention-suggestions";
+        list.hidden = true;
+        textarea.parentNode.insertBefore(list, textarea.nextSibling);
+
+        // Returns the partial username typed right before the caret, or null.
+        function currentPrefix() {
+            var before = textarea.value.slice(0, textarea.selectionStart);
+            var match = before.match(/@([\p{L}\p{N}_.-]*)$/u);
+            return match ? match[1] : null;
+        }
+
+        // Replaces the partial username before the caret with the chosen one.
+        function complete(username) {
+            var caret = textarea.selectionStart;
+            var before = textarea.value.slice(0, caret).replace(/@([\p{L}\p{N}_.-]*)$/u, "@" + username + " ");
+            textarea.value = before + textarea.value.slice(caret);
+            textarea.selectionStart = textarea.selectionEnd = before.length;
+            list.hidden = true;
+            textarea.focus();
+        }
+
+        textarea.addEventListener("input", function () {
+            var prefix = currentPrefix();
+            if (!prefix) {
+                list.hidden = true;
+                return;
+            }
+            fetch("/users/search?q=" + encodeURIComponent(prefix))
+                .then(function (response) { return response.json(); })
+                .then(function (usernames) {
+                    list.innerHTML = "";
+                    usernames.forEach(function (username) {
+                        var item = document.createElement("li");
+                        item.textContent = "@" + username;
+                        item.addEventListener("mousedown", function (e) {
+                            e.preventDefault();
+                            complete(username);
+                        });
+                        list.appendChild(item);
+                    });
+                    list.hidden = usernames.length === 0;
+                });
+        });
+
+        textarea.addEventListener("blur", function () {
+            list.hidden = true;
+        });
+    });
+})();
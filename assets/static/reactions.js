@@ -0,0 +1,43 @@
+// Sends reaction forms in the background and updates the counts without reloading the page.
+// Without JavaScript the forms still work and redirect back to the post.
+// Submits are handled on the document, so comments added by live updates work as well.
+(function () {
+    document.addEventListener("submit", function (e) {
+        var form = e.target;
+        if (!form.classList.contains("reaction-form")) {
+            return;
+        }
+        e.preventDefault();
+        fetch(form.action, {
+            method: "POST",
+            headers: { "Accept": "application/json" },
+            body: new URLSearchParams(new FormData(form))
+        })
+            .then(function (response) {
+                if (!response.ok) {
+                    throw new Error("reaction failed");
+                }
+                return response.json();
+            })
+            .then(function (result) {
+                var prefix = result.target_type + "-" + result.target_id;
+                Object.keys(result.counts).forEach(function (reaction) {
+                    var count = document.getElementById(prefix + "-reaction-" + reaction);
+                    if (count) {
+                        count.textContent = result.counts[reaction];
+                    }
+                });
+                // Each user has one reaction per target, so only the chosen button stays active.
+                form.parentNode.querySelectorAll("form.reaction-form button").forEach(function (button) {
+                    button.classList.remove("active");
+                });
+                if (result.active) {
+                    form.querySelector("button").classList.add("active");
+                }
+            })
+            .catch(function () {
+                // Fall back to a normal submit so the user sees the error page.
+                form.submit();
+            });
+    });
+})();
@@ -2,15 +2,18 @@ package database
 
 import (
 	"database/sql" // Import the package for database operations.
+	"fmt"          // Import the package for formatting errors.
 	"log"          // Import the package for logging errors or messages.
+	"strings"      // Import the package for reading trigger names.
 
 	_ "github.com/mattn/go-sqlite3" // Import SQLite3 driver for database interaction (side-effect import).
 )
 
 // InitDB initializes the database connection and sets up the schema.
 func InitDB(filepath string) *sql.DB {
-	// Open a connection to the SQLite database using the provided file path.
-	db, err := sql.Open("sqlite3", filepath)
+	// Open a connection to the SQLite database using the provided file path. SQLite only enforces
+	// the FOREIGN KEY clauses of the schema when asked to, on every connection of the pool.
+	db, err := sql.Open("sqlite3", filepath+"?_foreign_keys=on")
 	if err != nil {
 		// Log a fatal error and terminate the application if the connection fails.
 		log.Fatal(err)
@@ -51,6 +54,7 @@ func createTables(db *sql.DB) error {
         id INTEGER PRIMARY KEY AUTOINCREMENT, -- Unique identifier for the category.
         name TEXT NOT NULL,                   -- Name of the category.
         description TEXT,                     -- Optional description of the category.
+        is_question BOOLEAN DEFAULT 0,        -- Whether every post in the category is a question.
         created_at DATETIME DEFAULT CURRENT_TIMESTAMP -- Timestamp of when the category was created.
     );`
 
@@ -63,6 +67,17 @@ func createTables(db *sql.DB) error {
         body TEXT NOT NULL,                   -- Content of the post.
         category_id INTEGER,                  -- ID of the category the post belongs to.
         created_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of when the post was created.
+        likes INTEGER DEFAULT 0,              -- Number of "like" reactions, kept up to date by triggers.
+        dislikes INTEGER DEFAULT 0,           -- Number of "dislike" reactions, kept up to date by triggers.
+        comment_count INTEGER DEFAULT 0,      -- Number of comments, kept up to date by triggers.
+        last_activity_at DATETIME,            -- Timestamp of the post or its newest comment, kept up to date by triggers.
+        is_question BOOLEAN DEFAULT 0,        -- Whether the author marked the post as a question.
+        accepted_comment_id INTEGER,          -- ID of the comment accepted as the answer, if any.
+        pin_scope TEXT,                       -- Where the post is pinned: 'category' or 'site'; NULL when not pinned.
+        pinned_at DATETIME,                   -- Timestamp of when the post was pinned.
+        locked BOOLEAN DEFAULT 0,             -- Whether the thread is closed to new comments.
+        publish_at DATETIME,                  -- When a scheduled post goes out; NULL once it is published.
+        hidden BOOLEAN DEFAULT 0,             -- Whether reports hid the post until a moderator reviews them.
         FOREIGN KEY (user_id) REFERENCES users(id),    -- Relationship to the "user" table.
         FOREIGN KEY (category_id) REFERENCES categories(id) -- Relationship to the "categories" table.
     );`
@@ -75,6 +90,9 @@ func createTables(db *sql.DB) error {
         user_id INTEGER,                      -- ID of the user who made the comment.
         body TEXT NOT NULL,                   -- Content of the comment.
         created_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of when the comment was created.
+        likes INTEGER DEFAULT 0,              -- Number of "like" reactions, kept up to date by triggers.
+        dislikes INTEGER DEFAULT 0,           -- Number of "dislike" reactions, kept up to date by triggers.
+        hidden BOOLEAN DEFAULT 0,             -- Whether reports hid the comment until a moderator reviews them.
         FOREIGN KEY (post_id) REFERENCES posts(id), -- Relationship to the "posts" table.
         FOREIGN KEY (user_id) REFERENCES users(id) -- Relationship to the "users" table.
     );`
@@ -86,12 +104,425 @@ func createTables(db *sql.DB) error {
 		user_id INTEGER,                      -- ID of the user giving the reaction.
 		target_id INTEGER,                    -- ID of the post or comment being reacted to.
 		target_type TEXT,                     -- Type of the target: 'post' or 'comment'.
-		is_like BOOLEAN,                      -- TRUE for like, FALSE for dislike.
+		is_like BOOLEAN,                      -- Legacy like/dislike flag, superseded by "reaction".
+		reaction TEXT,                        -- Key of the reaction, e.g. 'like', 'dislike' or 'love'.
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of when the reaction was made.
 		FOREIGN KEY (user_id) REFERENCES users(id), -- Relationship to the "users" table.
 		UNIQUE (user_id, target_id, target_type) -- Ensure each user can react to a target only once.
 	);`
 
+	// SQL query to create the `notifications` table if it does not already exist.
+	createNotificationsTable := `
+	CREATE TABLE IF NOT EXISTS notifications (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, -- Unique identifier for the notification.
+		user_id INTEGER NOT NULL,             -- ID of the user receiving the notification.
+		kind TEXT NOT NULL,                   -- Kind of activity, e.g., 'comment'.
+		message TEXT NOT NULL,                -- Human-readable text of the notification.
+		link TEXT,                            -- Optional URL the notification points to.
+		is_read BOOLEAN DEFAULT 0,            -- TRUE once the user has seen the notification.
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of when the notification was created.
+		FOREIGN KEY (user_id) REFERENCES users(id) -- Relationship to the "users" table.
+	);`
+
+	// SQL query to create the `mentions` table if it does not already exist.
+	createMentionsTable := `
+	CREATE TABLE IF NOT EXISTS mentions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, -- Unique identifier for the mention.
+		user_id INTEGER NOT NULL,             -- ID of the mentioned user.
+		author_id INTEGER NOT NULL,           -- ID of the user who wrote the mention.
+		target_type TEXT NOT NULL,            -- Where the mention appears: 'post' or 'comment'.
+		target_id INTEGER NOT NULL,           -- ID of the post or comment containing the mention.
+		post_id INTEGER NOT NULL,             -- ID of the post, or of the comment's post.
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of when the mention was made.
+		FOREIGN KEY (user_id) REFERENCES users(id),   -- Relationship to the "users" table.
+		FOREIGN KEY (author_id) REFERENCES users(id), -- Relationship to the "users" table.
+		FOREIGN KEY (post_id) REFERENCES posts(id),   -- Relationship to the "posts" table.
+		UNIQUE (user_id, target_type, target_id) -- A user is mentioned at most once per post or comment.
+	);`
+
+	// SQL query to create the `conversations` table if it does not already exist.
+	createConversationsTable := `
+	CREATE TABLE IF NOT EXISTS conversations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, -- Unique identifier for the conversation.
+		title TEXT,                           -- Optional title for group conversations.
+		created_by INTEGER NOT NULL,          -- ID of the user who started the conversation.
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of when the conversation was started.
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of the latest message.
+		FOREIGN KEY (created_by) REFERENCES users(id) -- Relationship to the "users" table.
+	);`
+
+	// SQL query to create the `conversation_members` table if it does not already exist.
+	createConversationMembersTable := `
+	CREATE TABLE IF NOT EXISTS conversation_members (
+		conversation_id INTEGER NOT NULL,     -- ID of the conversation.
+		user_id INTEGER NOT NULL,             -- ID of the member.
+		last_read_message_id INTEGER DEFAULT 0, -- ID of the newest message the member has read.
+		muted BOOLEAN DEFAULT 0,              -- TRUE if the member muted the conversation.
+		joined_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of when the member joined.
+		FOREIGN KEY (conversation_id) REFERENCES conversations(id), -- Relationship to the "conversations" table.
+		FOREIGN KEY (user_id) REFERENCES users(id), -- Relationship to the "users" table.
+		PRIMARY KEY (conversation_id, user_id) -- A user is a member of a conversation only once.
+	);`
+
+	// SQL query to create the `messages` table if it does not already exist.
+	createMessagesTable := `
+	CREATE TABLE IF NOT EXISTS messages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, -- Unique identifier for the message.
+		conversation_id INTEGER NOT NULL,     -- ID of the conversation the message belongs to.
+		user_id INTEGER NOT NULL,             -- ID of the sender.
+		body TEXT NOT NULL,                   -- Content of the message.
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of when the message was sent.
+		FOREIGN KEY (conversation_id) REFERENCES conversations(id), -- Relationship to the "conversations" table.
+		FOREIGN KEY (user_id) REFERENCES users(id) -- Relationship to the "users" table.
+	);`
+
+	// SQL query to create the `user_blocks` table if it does not already exist.
+	createUserBlocksTable := `
+	CREATE TABLE IF NOT EXISTS user_blocks (
+		blocker_id INTEGER NOT NULL,          -- ID of the user who blocked.
+		blocked_id INTEGER NOT NULL,          -- ID of the blocked user.
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of when the block was created.
+		FOREIGN KEY (blocker_id) REFERENCES users(id), -- Relationship to the "users" table.
+		FOREIGN KEY (blocked_id) REFERENCES users(id), -- Relationship to the "users" table.
+		PRIMARY KEY (blocker_id, blocked_id) -- A user can block another user only once.
+	);`
+
+	// SQL query to create the `user_follows` table if it does not already exist.
+	createUserFollowsTable := `
+	CREATE TABLE IF NOT EXISTS user_follows (
+		follower_id INTEGER NOT NULL,         -- ID of the user who follows.
+		followed_id INTEGER NOT NULL,         -- ID of the user being followed.
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of when the follow started.
+		FOREIGN KEY (follower_id) REFERENCES users(id), -- Relationship to the "users" table.
+		FOREIGN KEY (followed_id) REFERENCES users(id), -- Relationship to the "users" table.
+		PRIMARY KEY (follower_id, followed_id) -- A user can follow another user only once.
+	);`
+
+	// SQL query to create the `category_follows` table if it does not already exist.
+	createCategoryFollowsTable := `
+	CREATE TABLE IF NOT EXISTS category_follows (
+		user_id INTEGER NOT NULL,             -- ID of the user who follows the category.
+		category_id INTEGER NOT NULL,         -- ID of the followed category.
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of when the follow started.
+		FOREIGN KEY (user_id) REFERENCES users(id), -- Relationship to the "users" table.
+		FOREIGN KEY (category_id) REFERENCES categories(id), -- Relationship to the "categories" table.
+		PRIMARY KEY (user_id, category_id) -- A user can follow a category only once.
+	);`
+
+	// SQL query to create the `bookmark_lists` table if it does not already exist.
+	createBookmarkListsTable := `
+	CREATE TABLE IF NOT EXISTS bookmark_lists (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, -- Unique identifier for each reading list.
+		user_id INTEGER NOT NULL,             -- ID of the user who owns the list.
+		name TEXT NOT NULL,                   -- Name of the list (e.g., "To read").
+		is_public BOOLEAN DEFAULT 0,          -- Whether other users can see the list.
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of when the list was created.
+		FOREIGN KEY (user_id) REFERENCES users(id), -- Relationship to the "users" table.
+		UNIQUE (user_id, name)                -- List names are unique per user.
+	);`
+
+	// SQL query to create the `bookmarks` table if it does not already exist.
+	createBookmarksTable := `
+	CREATE TABLE IF NOT EXISTS bookmarks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, -- Unique identifier for each bookmark.
+		list_id INTEGER NOT NULL,             -- ID of the reading list holding the bookmark.
+		target_type TEXT NOT NULL,            -- Type of bookmarked content: "post" or "comment".
+		target_id INTEGER NOT NULL,           -- ID of the bookmarked post or comment.
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of when the bookmark was added.
+		FOREIGN KEY (list_id) REFERENCES bookmark_lists(id), -- Relationship to the "bookmark_lists" table.
+		UNIQUE (list_id, target_type, target_id) -- The same content is saved only once per list.
+	);`
+
+	// SQL query to create the `post_reads` table if it does not already exist.
+	createPostReadsTable := `
+	CREATE TABLE IF NOT EXISTS post_reads (
+		user_id INTEGER NOT NULL,             -- ID of the reader.
+		post_id INTEGER NOT NULL,             -- ID of the post that was read.
+		last_read_comment_id INTEGER DEFAULT 0, -- ID of the newest comment the reader has seen.
+		read_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of the latest visit.
+		FOREIGN KEY (user_id) REFERENCES users(id), -- Relationship to the "users" table.
+		FOREIGN KEY (post_id) REFERENCES posts(id), -- Relationship to the "posts" table.
+		PRIMARY KEY (user_id, post_id) -- One read marker per user and post.
+	);`
+
+	// SQL query to create the `profile_settings` table if it does not already exist.
+	createProfileSettingsTable := `
+	CREATE TABLE IF NOT EXISTS profile_settings (
+		user_id INTEGER PRIMARY KEY,          -- ID of the profile owner.
+		visibility TEXT DEFAULT 'public',     -- Who can see the profile: public, members or private.
+		show_bio BOOLEAN DEFAULT 1,           -- Whether the bio is shown on the public profile.
+		show_activity BOOLEAN DEFAULT 1,      -- Whether recent posts and comments are shown.
+		show_likes BOOLEAN DEFAULT 1,         -- Whether received likes are shown.
+		show_follows BOOLEAN DEFAULT 1,       -- Whether follower counts are shown.
+		FOREIGN KEY (user_id) REFERENCES users(id) -- Relationship to the "users" table.
+	);`
+
+	// SQL query to create the `reports` table if it does not already exist.
+	createReportsTable := `
+	CREATE TABLE IF NOT EXISTS reports (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, -- Unique identifier for each report.
+		reporter_id INTEGER NOT NULL,         -- ID of the user who filed the report.
+		target_type TEXT NOT NULL,            -- Type of reported content: "post", "comment" or "user".
+		target_id INTEGER NOT NULL,           -- ID of the reported post, comment or user.
+		reason TEXT NOT NULL,                 -- Reason category, e.g. spam, abuse or spoiler.
+		details TEXT,                         -- Optional free-text explanation from the reporter.
+		status TEXT DEFAULT 'open',           -- Triage state: open, dismissed or actioned.
+		weight INTEGER DEFAULT 1,             -- Weight of the report, from the reporter's trust level when it was filed.
+		resolved_by INTEGER,                  -- ID of the moderator who closed the report.
+		resolved_at DATETIME,                 -- Timestamp of when the report was closed.
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of when the report was filed.
+		FOREIGN KEY (reporter_id) REFERENCES users(id), -- Relationship to the "users" table.
+		FOREIGN KEY (resolved_by) REFERENCES users(id)  -- Relationship to the "users" table.
+	);`
+
+	// SQL query to create the `moderation_log` table if it does not already exist.
+	createModerationLogTable := `
+	CREATE TABLE IF NOT EXISTS moderation_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, -- Unique identifier for each log entry.
+		moderator_id INTEGER NOT NULL,        -- ID of the moderator who took the action.
+		action TEXT NOT NULL,                 -- Action taken: dismiss, delete, warn or suspend.
+		target_type TEXT NOT NULL,            -- Type of the affected content: "post", "comment" or "user".
+		target_id INTEGER NOT NULL,           -- ID of the affected post, comment or user.
+		report_id INTEGER,                    -- ID of the report that led to the action, if any.
+		details TEXT,                         -- Moderator's note.
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of the action.
+		FOREIGN KEY (moderator_id) REFERENCES users(id), -- Relationship to the "users" table.
+		FOREIGN KEY (report_id) REFERENCES reports(id)   -- Relationship to the "reports" table.
+	);`
+
+	// Triggers that keep the moderation log append-only.
+	createModerationLogTriggers := `
+	CREATE TRIGGER IF NOT EXISTS moderation_log_no_update
+	BEFORE UPDATE ON moderation_log
+	BEGIN
+		SELECT RAISE(ABORT, 'moderation log entries cannot be changed');
+	END;
+	CREATE TRIGGER IF NOT EXISTS moderation_log_no_delete
+	BEFORE DELETE ON moderation_log
+	BEGIN
+		SELECT RAISE(ABORT, 'moderation log entries cannot be deleted');
+	END;`
+
+	// SQL query to create the `user_sanctions` table if it does not already exist.
+	createUserSanctionsTable := `
+	CREATE TABLE IF NOT EXISTS user_sanctions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, -- Unique identifier for each sanction.
+		user_id INTEGER NOT NULL,             -- ID of the sanctioned user.
+		kind TEXT NOT NULL,                   -- Kind of sanction, e.g. suspension.
+		reason TEXT,                          -- Reason shown to the user.
+		expires_at DATETIME,                  -- End of the sanction; NULL means it does not expire.
+		lifted_at DATETIME,                   -- Set when a moderator lifts the sanction early.
+		created_by INTEGER NOT NULL,          -- ID of the moderator who imposed the sanction.
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of when the sanction was imposed.
+		FOREIGN KEY (user_id) REFERENCES users(id),   -- Relationship to the "users" table.
+		FOREIGN KEY (created_by) REFERENCES users(id) -- Relationship to the "users" table.
+	);`
+
+	// SQL query to create the `word_filters` table if it does not already exist.
+	createWordFiltersTable := `
+	CREATE TABLE IF NOT EXISTS word_filters (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, -- Unique identifier for each filter.
+		pattern TEXT NOT NULL,                -- Word or regular expression to look for.
+		is_regex BOOLEAN DEFAULT 0,           -- Whether the pattern is a regular expression rather than a plain word.
+		policy TEXT NOT NULL,                 -- What happens on a match: replace, hold or reject.
+		replacement TEXT,                     -- Text that replaces matches under the replace policy.
+		created_by INTEGER NOT NULL,          -- ID of the admin who added the filter.
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of when the filter was added.
+		FOREIGN KEY (created_by) REFERENCES users(id) -- Relationship to the "users" table.
+	);`
+
+	// SQL query to create the `held_submissions` table if it does not already exist.
+	createHeldSubmissionsTable := `
+	CREATE TABLE IF NOT EXISTS held_submissions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, -- Unique identifier for each held submission.
+		user_id INTEGER NOT NULL,             -- ID of the author.
+		target_type TEXT NOT NULL,            -- Kind of submission: "post" or "comment".
+		post_id INTEGER,                      -- Post a held comment belongs to; NULL for posts.
+		category_id INTEGER,                  -- Category of a held post; NULL for comments.
+		title TEXT,                           -- Title of a held post; NULL for comments.
+		body TEXT NOT NULL,                   -- Text of the submission.
+		reason TEXT NOT NULL,                 -- Why the submission was held, e.g. a matched filter.
+		poll TEXT,                            -- Poll of a held post, as JSON; NULL when there is none.
+		publish_at DATETIME,                  -- When a held post was scheduled to go out; NULL to publish on approval.
+		status TEXT DEFAULT 'pending',        -- Review state: pending, approved or rejected.
+		resolved_by INTEGER,                  -- ID of the moderator who reviewed the submission.
+		resolved_at DATETIME,                 -- Timestamp of the review.
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of the submission.
+		FOREIGN KEY (user_id) REFERENCES users(id),    -- Relationship to the "users" table.
+		FOREIGN KEY (resolved_by) REFERENCES users(id) -- Relationship to the "users" table.
+	);`
+
+	// SQL query to create the `spam_examples` table if it does not already exist.
+	createSpamExamplesTable := `
+	CREATE TABLE IF NOT EXISTS spam_examples (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, -- Unique identifier for each example.
+		label TEXT NOT NULL,                  -- Moderator's verdict: "spam" or "ham".
+		body TEXT NOT NULL,                   -- Text of the labelled post or comment.
+		created_by INTEGER NOT NULL,          -- ID of the moderator who labelled the text.
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of the decision.
+		FOREIGN KEY (created_by) REFERENCES users(id) -- Relationship to the "users" table.
+	);`
+
+	// SQL query to create the `spam_tokens` table if it does not already exist.
+	createSpamTokensTable := `
+	CREATE TABLE IF NOT EXISTS spam_tokens (
+		token TEXT PRIMARY KEY,               -- Lower-cased word.
+		spam_count INTEGER DEFAULT 0,         -- Number of spam examples containing the word.
+		ham_count INTEGER DEFAULT 0           -- Number of ham examples containing the word.
+	);`
+
+	// SQL query to create the `user_trust` table if it does not already exist.
+	createUserTrustTable := `
+	CREATE TABLE IF NOT EXISTS user_trust (
+		user_id INTEGER PRIMARY KEY,          -- ID of the user.
+		level INTEGER DEFAULT 0,              -- Trust level from 0 (new) to 3 (regular).
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of the last change of level.
+		FOREIGN KEY (user_id) REFERENCES users(id) -- Relationship to the "users" table.
+	);`
+
+	// SQL query to create the `user_reputation` table if it does not already exist.
+	createUserReputationTable := `
+	CREATE TABLE IF NOT EXISTS user_reputation (
+		user_id INTEGER PRIMARY KEY,          -- ID of the user.
+		score REAL DEFAULT 0,                 -- Decayed sum of the likes and dislikes received.
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of the last recomputation.
+		FOREIGN KEY (user_id) REFERENCES users(id) -- Relationship to the "users" table.
+	);`
+
+	// SQL query to create the `user_badges` table if it does not already exist.
+	createUserBadgesTable := `
+	CREATE TABLE IF NOT EXISTS user_badges (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, -- Unique identifier for each award.
+		user_id INTEGER NOT NULL,             -- ID of the user who earned the badge.
+		badge TEXT NOT NULL,                  -- Key of the badge, e.g. "first-review".
+		awarded_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of when the badge was earned.
+		UNIQUE (user_id, badge),              -- Each badge is earned once.
+		FOREIGN KEY (user_id) REFERENCES users(id) -- Relationship to the "users" table.
+	);`
+
+	// SQL query to create the `leaderboard_entries` table if it does not already exist.
+	createLeaderboardEntriesTable := `
+	CREATE TABLE IF NOT EXISTS leaderboard_entries (
+		period TEXT NOT NULL,                 -- "week", "month" or "all".
+		metric TEXT NOT NULL,                 -- "reputation", "posts" or "helpful".
+		category_id INTEGER NOT NULL,         -- Category of the leaderboard, or 0 for all categories.
+		rank INTEGER NOT NULL,                -- Position on the leaderboard, starting at 1.
+		user_id INTEGER NOT NULL,             -- ID of the ranked user.
+		score INTEGER NOT NULL,               -- Value of the metric for the user.
+		computed_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of the recomputation.
+		PRIMARY KEY (period, metric, category_id, rank),
+		FOREIGN KEY (user_id) REFERENCES users(id) -- Relationship to the "users" table.
+	);`
+
+	// SQL query to create the `polls` table if it does not already exist.
+	createPollsTable := `
+	CREATE TABLE IF NOT EXISTS polls (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, -- Unique identifier for each poll.
+		post_id INTEGER NOT NULL UNIQUE,      -- ID of the post the poll is attached to; one poll per post.
+		question TEXT NOT NULL,               -- Question put to the voters.
+		multiple BOOLEAN DEFAULT 0,           -- Whether voters may choose more than one option.
+		results TEXT DEFAULT 'always',        -- Who sees the results: "always", "voted" or "closed".
+		closes_at DATETIME,                   -- End of voting; NULL means the poll stays open.
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of when the poll was created.
+		FOREIGN KEY (post_id) REFERENCES posts(id) -- Relationship to the "posts" table.
+	);`
+
+	// SQL query to create the `poll_options` table if it does not already exist.
+	createPollOptionsTable := `
+	CREATE TABLE IF NOT EXISTS poll_options (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, -- Unique identifier for each option.
+		poll_id INTEGER NOT NULL,             -- ID of the poll the option belongs to.
+		label TEXT NOT NULL,                  -- Text of the option, e.g. a book title.
+		position INTEGER NOT NULL,            -- Order in which the options are shown.
+		FOREIGN KEY (poll_id) REFERENCES polls(id) -- Relationship to the "polls" table.
+	);`
+
+	// SQL query to create the `poll_ballots` table if it does not already exist.
+	createPollBallotsTable := `
+	CREATE TABLE IF NOT EXISTS poll_ballots (
+		poll_id INTEGER NOT NULL,             -- ID of the poll.
+		user_id INTEGER NOT NULL,             -- ID of the voter.
+		cast_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of the vote.
+		PRIMARY KEY (poll_id, user_id),       -- Each user votes once per poll.
+		FOREIGN KEY (poll_id) REFERENCES polls(id), -- Relationship to the "polls" table.
+		FOREIGN KEY (user_id) REFERENCES users(id)  -- Relationship to the "users" table.
+	);`
+
+	// SQL query to create the `poll_votes` table if it does not already exist.
+	createPollVotesTable := `
+	CREATE TABLE IF NOT EXISTS poll_votes (
+		poll_id INTEGER NOT NULL,             -- ID of the poll.
+		user_id INTEGER NOT NULL,             -- ID of the voter.
+		option_id INTEGER NOT NULL,           -- ID of the chosen option.
+		PRIMARY KEY (poll_id, user_id, option_id), -- An option is chosen at most once per ballot.
+		FOREIGN KEY (poll_id, user_id) REFERENCES poll_ballots(poll_id, user_id), -- Relationship to the "poll_ballots" table.
+		FOREIGN KEY (option_id) REFERENCES poll_options(id) -- Relationship to the "poll_options" table.
+	);`
+
+	// SQL query to create the `announcements` table if it does not already exist.
+	createAnnouncementsTable := `
+	CREATE TABLE IF NOT EXISTS announcements (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, -- Unique identifier for each announcement.
+		message TEXT NOT NULL,                -- Text shown to every visitor.
+		link TEXT,                            -- Optional URL the announcement points to.
+		created_by INTEGER NOT NULL,          -- ID of the moderator who posted the announcement.
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of when the announcement was posted.
+		ended_at DATETIME,                    -- Set when a moderator takes the announcement down.
+		FOREIGN KEY (created_by) REFERENCES users(id) -- Relationship to the "users" table.
+	);`
+
+	// SQL query to create the `announcement_dismissals` table if it does not already exist.
+	createAnnouncementDismissalsTable := `
+	CREATE TABLE IF NOT EXISTS announcement_dismissals (
+		announcement_id INTEGER NOT NULL,     -- ID of the dismissed announcement.
+		user_id INTEGER NOT NULL,             -- ID of the user who dismissed it.
+		dismissed_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of the dismissal.
+		PRIMARY KEY (announcement_id, user_id),
+		FOREIGN KEY (announcement_id) REFERENCES announcements(id), -- Relationship to the "announcements" table.
+		FOREIGN KEY (user_id) REFERENCES users(id) -- Relationship to the "users" table.
+	);`
+
+	// SQL query to create the `post_redirects` table if it does not already exist.
+	createPostRedirectsTable := `
+	CREATE TABLE IF NOT EXISTS post_redirects (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, -- Unique identifier for each redirect.
+		from_post_id INTEGER NOT NULL,        -- ID of the post the old URL points to.
+		comment_id INTEGER,                   -- ID of a comment split off the post; NULL when the whole post was merged away.
+		to_post_id INTEGER NOT NULL,          -- ID of the post that now holds the content.
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of the merge or split.
+		FOREIGN KEY (to_post_id) REFERENCES posts(id) -- Relationship to the "posts" table.
+	);`
+
+	// SQL query to create the `drafts` table if it does not already exist.
+	createDraftsTable := `
+	CREATE TABLE IF NOT EXISTS drafts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, -- Unique identifier for each draft.
+		user_id INTEGER NOT NULL,             -- ID of the author.
+		title TEXT NOT NULL DEFAULT '',       -- Title written so far.
+		body TEXT NOT NULL DEFAULT '',        -- Text written so far.
+		category_id INTEGER,                  -- Chosen category; NULL until one is chosen.
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of the first save.
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP, -- Timestamp of the latest save.
+		FOREIGN KEY (user_id) REFERENCES users(id),        -- Relationship to the "users" table.
+		FOREIGN KEY (category_id) REFERENCES categories(id) -- Relationship to the "categories" table.
+	);`
+
+	// Triggers that keep every vote on an option of its own poll, and single-choice ballots to one option.
+	createPollVoteTriggers := `
+	CREATE TRIGGER IF NOT EXISTS poll_votes_option_check
+	BEFORE INSERT ON poll_votes
+	WHEN NOT EXISTS (SELECT 1 FROM poll_options WHERE id = NEW.option_id AND poll_id = NEW.poll_id)
+	BEGIN
+		SELECT RAISE(ABORT, 'option does not belong to the poll');
+	END;
+	CREATE TRIGGER IF NOT EXISTS poll_votes_single_choice
+	BEFORE INSERT ON poll_votes
+	WHEN (SELECT multiple FROM polls WHERE id = NEW.poll_id) = 0
+	 AND EXISTS (SELECT 1 FROM poll_votes WHERE poll_id = NEW.poll_id AND user_id = NEW.user_id)
+	BEGIN
+		SELECT RAISE(ABORT, 'single-choice polls take one option per voter');
+	END;`
+
 	// SQL query to create the `sessions` table if it does not already exist.
 	createSessionsTable := `
     CREATE TABLE IF NOT EXISTS sessions (
@@ -132,10 +563,430 @@ func createTables(db *sql.DB) error {
 		return err
 	}
 
+	_, err = db.Exec(createNotificationsTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createMentionsTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createConversationsTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createConversationMembersTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createMessagesTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createUserBlocksTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createUserFollowsTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createCategoryFollowsTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createBookmarkListsTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createBookmarksTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createPostReadsTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createProfileSettingsTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createReportsTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createModerationLogTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createModerationLogTriggers)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createUserSanctionsTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createWordFiltersTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createHeldSubmissionsTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createSpamExamplesTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createSpamTokensTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createUserTrustTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createUserReputationTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createUserBadgesTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createLeaderboardEntriesTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createPollsTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createPollOptionsTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createPollBallotsTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createPollVotesTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createPollVoteTriggers)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createAnnouncementsTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createAnnouncementDismissalsTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createPostRedirectsTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(createDraftsTable)
+	if err != nil {
+		return err
+	}
+
+	// Bring databases created by older versions up to date.
+	err = migrateReactions(db)
+	if err != nil {
+		return err
+	}
+
+	err = migrateCounters(db)
+	if err != nil {
+		return err
+	}
+
+	err = migrateAddedColumns(db)
+	if err != nil {
+		return err
+	}
+
 	// Return nil to indicate success if no errors occurred.
 	return nil
 }
 
+// migrateReactions adds the "reaction" column to databases created before emoji reactions
+// and maps every old like/dislike row onto the "like" or "dislike" reaction.
+func migrateReactions(db *sql.DB) error {
+	if _, err := addColumnIfMissing(db, "likes_dislikes", "reaction", "TEXT"); err != nil {
+		return err
+	}
+	_, err := db.Exec(`
+		UPDATE likes_dislikes SET reaction = CASE WHEN is_like THEN 'like' ELSE 'dislike' END
+		WHERE reaction IS NULL`)
+	return err
+}
+
+// addedColumns are the columns added to existing tables that older databases lack and that
+// need no backfilling, by table.
+var addedColumns = []struct{ table, column, definition string }{
+	{"held_submissions", "poll", "TEXT"},               // Posts held for review keep their poll
+	{"categories", "is_question", "BOOLEAN DEFAULT 0"}, // Q&A categories
+	{"posts", "is_question", "BOOLEAN DEFAULT 0"},      // Questions
+	{"posts", "accepted_comment_id", "INTEGER"},        // Accepted answers
+	{"posts", "pin_scope", "TEXT"},                     // Pinned threads
+	{"posts", "pinned_at", "DATETIME"},                 // Pinned threads
+	{"posts", "locked", "BOOLEAN DEFAULT 0"},           // Locked threads
+	{"posts", "publish_at", "DATETIME"},                // Scheduled posts
+	{"held_submissions", "publish_at", "DATETIME"},     // Held posts keep their schedule
+	{"reports", "weight", "INTEGER DEFAULT 1"},         // Flagging weight
+	{"posts", "hidden", "BOOLEAN DEFAULT 0"},           // Posts hidden by reports
+	{"comments", "hidden", "BOOLEAN DEFAULT 0"},        // Comments hidden by reports
+}
+
+// migrateAddedColumns adds the columns in addedColumns that are missing.
+func migrateAddedColumns(db *sql.DB) error {
+	for _, c := range addedColumns {
+		if _, err := addColumnIfMissing(db, c.table, c.column, c.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// counterColumns are the denormalized counters added to older databases, by table.
+var counterColumns = []struct{ table, column, definition string }{
+	{"posts", "likes", "INTEGER DEFAULT 0"},
+	{"posts", "dislikes", "INTEGER DEFAULT 0"},
+	{"posts", "comment_count", "INTEGER DEFAULT 0"},
+	{"posts", "last_activity_at", "DATETIME"},
+	{"comments", "likes", "INTEGER DEFAULT 0"},
+	{"comments", "dislikes", "INTEGER DEFAULT 0"},
+}
+
+// shadowbannedUsers selects the users whose comments are visible only to themselves; their
+// comments are left out of the public comment counts and activity times.
+const shadowbannedUsers = "(SELECT user_id FROM user_sanctions WHERE kind = 'shadowban' AND lifted_at IS NULL)"
+
+// recountShadowbannedComments recounts the comments and activity of every post the sanction's
+// user commented on, for the triggers that fire when a shadowban is imposed or lifted.
+const recountShadowbannedComments = `
+		UPDATE posts SET
+			comment_count = (SELECT COUNT(*) FROM comments
+			                 WHERE post_id = posts.id AND user_id NOT IN ` + shadowbannedUsers + `),
+			last_activity_at = COALESCE((SELECT MAX(created_at) FROM comments
+			                             WHERE post_id = posts.id AND user_id NOT IN ` + shadowbannedUsers + `), created_at)
+		WHERE id IN (SELECT post_id FROM comments WHERE user_id = NEW.user_id);`
+
+// counterTriggers keep the counters on posts and comments in step with every write to
+// "comments", "likes_dislikes" and shadowbans, whichever code path makes it.
+var counterTriggers = []string{
+	`CREATE TRIGGER IF NOT EXISTS posts_activity_insert AFTER INSERT ON posts
+	BEGIN
+		UPDATE posts SET last_activity_at = NEW.created_at WHERE id = NEW.id AND last_activity_at IS NULL;
+	END;`,
+	`CREATE TRIGGER IF NOT EXISTS comments_count_insert AFTER INSERT ON comments
+	WHEN NEW.user_id NOT IN ` + shadowbannedUsers + `
+	BEGIN
+		UPDATE posts SET comment_count = comment_count + 1, last_activity_at = NEW.created_at WHERE id = NEW.post_id;
+	END;`,
+	`CREATE TRIGGER IF NOT EXISTS comments_count_delete AFTER DELETE ON comments
+	WHEN OLD.user_id NOT IN ` + shadowbannedUsers + `
+	BEGIN
+		UPDATE posts SET comment_count = comment_count - 1 WHERE id = OLD.post_id;
+	END;`,
+	`CREATE TRIGGER IF NOT EXISTS comments_count_move AFTER UPDATE OF post_id ON comments
+	WHEN OLD.post_id <> NEW.post_id AND NEW.user_id NOT IN ` + shadowbannedUsers + `
+	BEGIN
+		UPDATE posts SET comment_count = comment_count - 1 WHERE id = OLD.post_id;
+		UPDATE posts SET comment_count = comment_count + 1,
+		                 last_activity_at = MAX(COALESCE(last_activity_at, NEW.created_at), NEW.created_at)
+		WHERE id = NEW.post_id;
+	END;`,
+	`CREATE TRIGGER IF NOT EXISTS shadowban_counts_insert AFTER INSERT ON user_sanctions
+	WHEN NEW.kind = 'shadowban'
+	BEGIN` + recountShadowbannedComments + `
+	END;`,
+	`CREATE TRIGGER IF NOT EXISTS shadowban_counts_lift AFTER UPDATE OF lifted_at ON user_sanctions
+	WHEN NEW.kind = 'shadowban'
+	BEGIN` + recountShadowbannedComments + `
+	END;`,
+	`CREATE TRIGGER IF NOT EXISTS reactions_count_insert AFTER INSERT ON likes_dislikes
+	BEGIN
+		UPDATE posts SET likes = likes + (NEW.reaction = 'like'), dislikes = dislikes + (NEW.reaction = 'dislike')
+		WHERE NEW.target_type = 'post' AND id = NEW.target_id;
+		UPDATE comments SET likes = likes + (NEW.reaction = 'like'), dislikes = dislikes + (NEW.reaction = 'dislike')
+		WHERE NEW.target_type = 'comment' AND id = NEW.target_id;
+	END;`,
+	`CREATE TRIGGER IF NOT EXISTS reactions_count_delete AFTER DELETE ON likes_dislikes
+	BEGIN
+		UPDATE posts SET likes = likes - (OLD.reaction = 'like'), dislikes = dislikes - (OLD.reaction = 'dislike')
+		WHERE OLD.target_type = 'post' AND id = OLD.target_id;
+		UPDATE comments SET likes = likes - (OLD.reaction = 'like'), dislikes = dislikes - (OLD.reaction = 'dislike')
+		WHERE OLD.target_type = 'comment' AND id = OLD.target_id;
+	END;`,
+	`CREATE TRIGGER IF NOT EXISTS reactions_count_update AFTER UPDATE OF reaction ON likes_dislikes
+	BEGIN
+		UPDATE posts SET likes = likes - (OLD.reaction = 'like') + (NEW.reaction = 'like'),
+		                 dislikes = dislikes - (OLD.reaction = 'dislike') + (NEW.reaction = 'dislike')
+		WHERE NEW.target_type = 'post' AND id = NEW.target_id;
+		UPDATE comments SET likes = likes - (OLD.reaction = 'like') + (NEW.reaction = 'like'),
+		                    dislikes = dislikes - (OLD.reaction = 'dislike') + (NEW.reaction = 'dislike')
+		WHERE NEW.target_type = 'comment' AND id = NEW.target_id;
+	END;`,
+}
+
+// migrateCounters adds the counter columns and their triggers. Databases that did not have the
+// counters yet get them filled in from the source tables.
+func migrateCounters(db *sql.DB) error {
+	rebuild := false
+	for _, c := range counterColumns {
+		columnAdded, err := addColumnIfMissing(db, c.table, c.column, c.definition)
+		if err != nil {
+			return err
+		}
+		rebuild = rebuild || columnAdded
+	}
+	// Triggers whose definition changed are replaced, and the counters they kept are rebuilt.
+	// Each definition starts with "CREATE TRIGGER IF NOT EXISTS <name>"; SQLite stores it
+	// without the "IF NOT EXISTS" and the final semicolon.
+	for _, trigger := range counterTriggers {
+		name := strings.Fields(trigger)[5]
+		var stored string
+		err := db.QueryRow("SELECT sql FROM sqlite_master WHERE type = 'trigger' AND name = ?", name).Scan(&stored)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if stored == strings.TrimSuffix(strings.Replace(trigger, "IF NOT EXISTS ", "", 1), ";") {
+			continue
+		}
+		if _, err := db.Exec("DROP TRIGGER IF EXISTS " + name); err != nil {
+			return err
+		}
+		if _, err := db.Exec(trigger); err != nil {
+			return err
+		}
+		rebuild = rebuild || stored != ""
+	}
+	if rebuild {
+		return ReconcileCounters(db)
+	}
+	return nil
+}
+
+// ReconcileCounters rebuilds the like, dislike, comment and activity counters of every post and
+// comment from the "likes_dislikes" and "comments" tables, in case they drifted. Comments of
+// shadowbanned users are not counted.
+func ReconcileCounters(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE posts SET
+			likes = (SELECT COUNT(*) FROM likes_dislikes
+			         WHERE target_type = 'post' AND target_id = posts.id AND reaction = 'like'),
+			dislikes = (SELECT COUNT(*) FROM likes_dislikes
+			            WHERE target_type = 'post' AND target_id = posts.id AND reaction = 'dislike'),
+			comment_count = (SELECT COUNT(*) FROM comments
+			                 WHERE post_id = posts.id AND user_id NOT IN ` + shadowbannedUsers + `),
+			last_activity_at = COALESCE((SELECT MAX(created_at) FROM comments
+			                             WHERE post_id = posts.id AND user_id NOT IN ` + shadowbannedUsers + `), created_at)`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE comments SET
+			likes = (SELECT COUNT(*) FROM likes_dislikes
+			         WHERE target_type = 'comment' AND target_id = comments.id AND reaction = 'like'),
+			dislikes = (SELECT COUNT(*) FROM likes_dislikes
+			            WHERE target_type = 'comment' AND target_id = comments.id AND reaction = 'dislike')`)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// addColumnIfMissing adds a column to an existing table unless the table already has it,
+// and reports whether it was added.
+func addColumnIfMissing(db *sql.DB, table, column, definition string) (bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, columnType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultValue, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return false, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err == nil, err
+}
+
+// SetUserRole changes the role (e.g. "member", "moderator" or "admin") of the user with the given username.
+func SetUserRole(db *sql.DB, username, role string) error {
+	result, err := db.Exec("UPDATE users SET role = ? WHERE username = ?", role, username)
+	if err != nil {
+		return err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return fmt.Errorf("user %q not found", username)
+	}
+	return nil
+}
+
 // addMockData populates the database with sample data if it's empty.
 func addMockData(db *sql.DB) {
 	// Check if there are any existing users in the `users` table.
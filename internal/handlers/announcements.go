@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"database/sql"                          // Provides SQL database support
+	"encoding/json"                         // Used to send announcements to the header script
+	models "literary-lions/internal/models" // Imports the models package for structured data types
+	"log"                                   // Used for logging errors
+	"net/http"                              // Provides HTTP client and server implementations
+	"strconv"                               // Used to parse announcement IDs
+	"strings"                               // Used to trim the message
+	"time"                                  // Used to timestamp announcements
+)
+
+// AnnouncementsHandler answers "/announcements" with the running announcements as JSON,
+// leaving out the ones the logged-in user dismissed. The header script shows them on every page,
+// which keeps the announcements out of the page data of every handler.
+func AnnouncementsHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only GET requests are supported.
+	if r.Method != http.MethodGet {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	rows, err := db.Query(`
+		SELECT a.id, a.message, COALESCE(a.link, '')
+		FROM announcements a
+		WHERE a.ended_at IS NULL
+		  AND a.id NOT IN (SELECT announcement_id FROM announcement_dismissals WHERE user_id = ?)
+		ORDER BY a.created_at DESC`, viewerID(r, db))
+	if err != nil {
+		log.Printf("Error loading announcements: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading announcements")
+		return
+	}
+	defer rows.Close()
+
+	type announcement struct {
+		ID      int    `json:"id"`
+		Message string `json:"message"`
+		Link    string `json:"link,omitempty"`
+	}
+	announcements := []announcement{}
+	for rows.Next() {
+		var a announcement
+		if err := rows.Scan(&a.ID, &a.Message, &a.Link); err != nil {
+			log.Printf("Error reading announcements: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading announcements")
+			return
+		}
+		announcements = append(announcements, a)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error parsing announcements: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading announcements")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(announcements)
+}
+
+// DismissAnnouncementHandler hides an announcement from the logged-in user for good.
+// Visitors dismiss announcements in their browser only.
+func DismissAnnouncementHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests change state.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	userID, err := GetUserIDFromSession(r, db)
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusUnauthorized, "User is not authorised")
+		return
+	}
+
+	announcementID, err := strconv.Atoi(r.FormValue("announcement_id"))
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Incorrect data")
+		return
+	}
+
+	_, err = db.Exec(`
+		INSERT OR IGNORE INTO announcement_dismissals (announcement_id, user_id, dismissed_at)
+		VALUES (?, ?, ?)`, announcementID, userID, time.Now())
+	if err != nil {
+		log.Printf("Error dismissing announcement: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error dismissing announcement")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ManageAnnouncementsHandler lets moderators "post" a site-wide announcement or "end" a running one.
+// Both are recorded in the moderation log.
+func ManageAnnouncementsHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests change state.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	moderator, ok := requireModerator(w, r, db)
+	if !ok {
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error saving the announcement")
+		return
+	}
+	defer tx.Rollback()
+
+	action := r.FormValue("action")
+	var announcementID int
+	switch action {
+	case "post":
+		message := strings.TrimSpace(r.FormValue("message"))
+		link := strings.TrimSpace(r.FormValue("link"))
+		if message == "" {
+			moderationError(w, r, "The announcement needs a message")
+			return
+		}
+		// Only local links are accepted, so announcements cannot send visitors elsewhere.
+		var storedLink interface{}
+		if link != "" {
+			if !isLocalPath(link) {
+				moderationError(w, r, "Announcement links must be paths on this site, starting with a single /")
+				return
+			}
+			storedLink = link
+		}
+		var result sql.Result
+		result, err = tx.Exec("INSERT INTO announcements (message, link, created_by, created_at) VALUES (?, ?, ?, ?)",
+			message, storedLink, moderator.ID, time.Now())
+		if err == nil {
+			var id int64
+			id, err = result.LastInsertId()
+			announcementID = int(id)
+		}
+	case "end":
+		announcementID, err = strconv.Atoi(r.FormValue("announcement_id"))
+		if err != nil {
+			RenderErrorPage(w, r, db, http.StatusBadRequest, "Incorrect data")
+			return
+		}
+		_, err = tx.Exec("UPDATE announcements SET ended_at = ? WHERE id = ? AND ended_at IS NULL", time.Now(), announcementID)
+	default:
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Unknown action")
+		return
+	}
+	if err == nil {
+		err = logModeration(tx, moderator.ID, action+"-announcement", "announcement", announcementID, 0, r.FormValue("message"))
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("Error saving the announcement: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error saving the announcement")
+		return
+	}
+
+	http.Redirect(w, r, "/moderation", http.StatusSeeOther)
+}
+
+// loadAnnouncements returns the running announcements, newest first.
+func loadAnnouncements(db *sql.DB) ([]models.Announcement, error) {
+	rows, err := db.Query(`
+		SELECT a.id, a.message, COALESCE(a.link, ''), a.created_at, u.username
+		FROM announcements a
+		JOIN users u ON u.id = a.created_by
+		WHERE a.ended_at IS NULL
+		ORDER BY a.created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var announcements []models.Announcement
+	for rows.Next() {
+		var a models.Announcement
+		if err := rows.Scan(&a.ID, &a.Message, &a.Link, &a.CreatedAt, &a.CreatedBy); err != nil {
+			return nil, err
+		}
+		announcements = append(announcements, a)
+	}
+	return announcements, rows.Err()
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"database/sql"                          // Provides SQL database support
+	"fmt"                                   // Used to format notifications
+	models "literary-lions/internal/models" // Imports the models package for structured data types
+	"log"                                   // Used for logging errors
+	"net/url"                               // Used to escape profile links
+	"time"                                  // Used to timestamp awards
+)
+
+// Events that trigger badge rules.
+const (
+	badgeEventPost    = "post"    // The user published a post
+	badgeEventComment = "comment" // The user published a comment
+	badgeEventLike    = "like"    // One of the user's posts or comments was liked
+)
+
+// badgeRule defines one badge and when the user has earned it.
+type badgeRule struct {
+	Key         string                                     // Stored in "user_badges.badge"
+	Name        string                                     // Shown on profiles
+	Description string                                     // Explains how the badge is earned
+	Events      []string                                   // Events that re-evaluate the rule; the nightly batch checks every rule
+	Earned      func(db *sql.DB, userID int) (bool, error) // Whether the user qualifies
+}
+
+// badgeRules lists every badge, in the order they are shown.
+var badgeRules = []badgeRule{
+	{
+		Key:         "first-review",
+		Name:        "First Review",
+		Description: "Published a first post",
+		Events:      []string{badgeEventPost},
+		Earned: func(db *sql.DB, userID int) (bool, error) {
+			return countAtLeast(db, 1, "SELECT COUNT(*) FROM posts WHERE user_id = ? AND publish_at IS NULL", userID)
+		},
+	},
+	{
+		Key:         "100-likes",
+		Name:        "100 Likes",
+		Description: "Received 100 likes from other members",
+		Events:      []string{badgeEventLike},
+		Earned: func(db *sql.DB, userID int) (bool, error) {
+			return countAtLeast(db, 100, `
+				SELECT COUNT(*) FROM likes_dislikes l
+				WHERE l.reaction = 'like' AND l.user_id != ? AND (
+					(l.target_type = 'post' AND l.target_id IN (SELECT id FROM posts WHERE user_id = ?)) OR
+					(l.target_type = 'comment' AND l.target_id IN (SELECT id FROM comments WHERE user_id = ?)))`,
+				userID, userID, userID)
+		},
+	},
+	{
+		Key:         "bookworm",
+		Name:        "Bookworm",
+		Description: "Commented in every category",
+		Events:      []string{badgeEventComment},
+		Earned: func(db *sql.DB, userID int) (bool, error) {
+			var missing int
+			err := db.QueryRow(`
+				SELECT COUNT(*) FROM categories
+				WHERE id NOT IN (
+					SELECT p.category_id FROM comments c JOIN posts p ON p.id = c.post_id WHERE c.user_id = ?)`,
+				userID).Scan(&missing)
+			return missing == 0, err
+		},
+	},
+	{
+		Key:         "anniversary",
+		Name:        "Anniversary",
+		Description: "Member for a year",
+		Earned: func(db *sql.DB, userID int) (bool, error) {
+			var createdAt time.Time
+			err := db.QueryRow("SELECT created_at FROM users WHERE id = ?", userID).Scan(&createdAt)
+			return err == nil && !createdAt.After(time.Now().AddDate(-1, 0, 0)), err
+		},
+	},
+}
+
+// countAtLeast runs a COUNT query and reports whether the result reaches min.
+func countAtLeast(db *sql.DB, min int, query string, args ...interface{}) (bool, error) {
+	var count int
+	err := db.QueryRow(query, args...).Scan(&count)
+	return count >= min, err
+}
+
+// awardBadges evaluates the rules that listen to event for the user. An empty event
+// evaluates every rule, as the nightly batch does. Errors are logged, not returned,
+// because badges never block the action that triggered them.
+func awardBadges(db *sql.DB, userID int, event string) {
+	for _, rule := range badgeRules {
+		if event != "" && !containsString(rule.Events, event) {
+			continue
+		}
+		if err := evaluateBadge(db, userID, rule); err != nil {
+			log.Printf("Error evaluating badge %q: %v", rule.Key, err)
+		}
+	}
+}
+
+// awardBadgesForContent evaluates the badge rules for the author of a post or comment.
+func awardBadgesForContent(db *sql.DB, targetType string, targetID int, event string) {
+	var authorID int
+	query := "SELECT user_id FROM posts WHERE id = ?"
+	if targetType == "comment" {
+		query = "SELECT user_id FROM comments WHERE id = ?"
+	}
+	if err := db.QueryRow(query, targetID).Scan(&authorID); err != nil {
+		log.Printf("Error loading content author for badges: %v", err)
+		return
+	}
+	awardBadges(db, authorID, event)
+}
+
+// evaluateBadge awards the rule's badge and notifies the user if they qualify and do not have it yet.
+func evaluateBadge(db *sql.DB, userID int, rule badgeRule) error {
+	var has bool
+	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM user_badges WHERE user_id = ? AND badge = ?)", userID, rule.Key).Scan(&has)
+	if err != nil || has {
+		return err
+	}
+
+	earned, err := rule.Earned(db, userID)
+	if err != nil || !earned {
+		return err
+	}
+
+	// INSERT OR IGNORE keeps a concurrent evaluation from awarding the badge twice.
+	result, err := db.Exec("INSERT OR IGNORE INTO user_badges (user_id, badge, awarded_at) VALUES (?, ?, ?)",
+		userID, rule.Key, time.Now())
+	if err != nil {
+		return err
+	}
+	if added, _ := result.RowsAffected(); added == 0 {
+		return nil
+	}
+
+	var username string
+	if err := db.QueryRow("SELECT username FROM users WHERE id = ?", userID).Scan(&username); err != nil {
+		return err
+	}
+	message := fmt.Sprintf("You earned the badge \"%s\": %s", rule.Name, rule.Description)
+	return CreateNotification(db, userID, "badge", message, "/u/"+url.PathEscape(username))
+}
+
+// AwardAllBadges is the nightly batch that evaluates every badge rule for every user,
+// catching badges that no event triggers, such as anniversaries.
+func AwardAllBadges(db *sql.DB) error {
+	rows, err := db.Query("SELECT id FROM users")
+	if err != nil {
+		return err
+	}
+	var userIDs []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		awardBadges(db, userID, "")
+	}
+	return nil
+}
+
+// loadBadges returns the badges the user has earned, in the order of badgeRules.
+func loadBadges(db *sql.DB, userID int) ([]models.Badge, error) {
+	rows, err := db.Query("SELECT badge, awarded_at FROM user_badges WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	awarded := make(map[string]time.Time)
+	for rows.Next() {
+		var key string
+		var awardedAt time.Time
+		if err := rows.Scan(&key, &awardedAt); err != nil {
+			return nil, err
+		}
+		awarded[key] = awardedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var badges []models.Badge
+	for _, rule := range badgeRules {
+		if awardedAt, ok := awarded[rule.Key]; ok {
+			badges = append(badges, models.Badge{
+				Key:         rule.Key,
+				Name:        rule.Name,
+				Description: rule.Description,
+				AwardedAt:   awardedAt,
+			})
+		}
+	}
+	return badges, nil
+}
+
+// containsString reports whether list contains value.
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
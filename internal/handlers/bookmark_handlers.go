@@ -0,0 +1,357 @@
+package handlers
+
+import (
+	"database/sql"                          // Provides SQL database support
+	"html/template"                         // Used for rendering HTML templates
+	models "literary-lions/internal/models" // Imports the models package for structured data types
+	"log"                                   // Used for logging errors
+	"net/http"                              // Provides HTTP client and server implementations
+	"net/url"                               // Used to escape error messages in redirects
+	"strconv"                               // Used to parse IDs from forms and query strings
+	"strings"                               // Used for string manipulation
+	"time"                                  // Used to timestamp lists and bookmarks
+)
+
+// defaultBookmarkList is created on demand the first time a user bookmarks something without picking a list.
+const defaultBookmarkList = "To read"
+
+// UserBookmarksHandler renders "/user/bookmarks". Without parameters it shows the logged-in user's lists;
+// with "?user_id=" it shows the public lists of another user.
+func UserBookmarksHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only GET requests are supported.
+	if r.Method != http.MethodGet {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	// The viewer may be anonymous when looking at someone's public lists.
+	var user *models.User
+	viewerID, err := GetUserIDFromSession(r, db)
+	if err == nil {
+		user = &models.User{}
+		err = db.QueryRow("SELECT id, username FROM users WHERE id = ?", viewerID).Scan(&user.ID, &user.Username)
+		if err != nil {
+			log.Printf("Error getting the user: %v", err)
+			user = nil
+		}
+	}
+
+	// Work out whose lists are shown.
+	ownerID := 0
+	if userIDStr := r.URL.Query().Get("user_id"); userIDStr != "" {
+		ownerID, err = strconv.Atoi(userIDStr)
+		if err != nil {
+			RenderErrorPage(w, r, db, http.StatusBadRequest, "Invalid user ID")
+			return
+		}
+	} else if user != nil {
+		ownerID = user.ID
+	} else {
+		RenderErrorPage(w, r, db, http.StatusUnauthorized, "User is not authorised")
+		return
+	}
+
+	owner := &models.User{}
+	err = db.QueryRow("SELECT id, username FROM users WHERE id = ?", ownerID).Scan(&owner.ID, &owner.Username)
+	if err == sql.ErrNoRows {
+		RenderErrorPage(w, r, db, http.StatusNotFound, "User is not found")
+		return
+	} else if err != nil {
+		log.Printf("Error getting the list owner: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading bookmarks")
+		return
+	}
+	isOwner := user != nil && user.ID == ownerID
+
+	// Private lists are only visible to their owner.
+	lists, err := loadBookmarkLists(db, ownerID, !isOwner)
+	if err != nil {
+		log.Printf("Error loading bookmark lists: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading bookmarks")
+		return
+	}
+
+	// Attach the bookmarked posts and comments to each list.
+	for i := range lists {
+		lists[i].Items, err = loadBookmarks(db, lists[i].ID)
+		if err != nil {
+			log.Printf("Error loading bookmarks: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading bookmarks")
+			return
+		}
+	}
+
+	categories, err := loadCategories(db)
+	if err != nil {
+		log.Printf("Error loading categories: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading categories")
+		return
+	}
+
+	pageData := models.BookmarksPageData{
+		User:         user,
+		Owner:        owner,
+		IsOwner:      isOwner,
+		Lists:        lists,
+		Categories:   categories,
+		ErrorMessage: r.URL.Query().Get("error"),
+	}
+
+	tmpl, err := template.ParseFiles("assets/template/header.html", "assets/template/user_bookmarks.html")
+	if err != nil {
+		log.Printf("Error loading template: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading template")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	if err := tmpl.ExecuteTemplate(w, "user_bookmarks", pageData); err != nil {
+		log.Printf("Rendering error: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Rendering page error")
+	}
+}
+
+// AddBookmarkHandler bookmarks a post or comment into one of the user's lists.
+// The list is picked by "list_id", created from "new_list", or defaults to "To read".
+func AddBookmarkHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests change state.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	userID, err := GetUserIDFromSession(r, db)
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusUnauthorized, "User is not authorised")
+		return
+	}
+
+	targetType := r.FormValue("target_type")
+	targetID, err := strconv.Atoi(r.FormValue("target_id"))
+	if err != nil || (targetType != "post" && targetType != "comment") {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Incorrect data")
+		return
+	}
+
+	// Make sure the bookmarked content exists and is published.
+	var exists bool
+	if targetType == "post" {
+		err = db.QueryRow("SELECT EXISTS(SELECT 1 FROM posts p WHERE p.id = ? AND "+publishedPost+")", targetID).Scan(&exists)
+	} else {
+		err = db.QueryRow(`
+			SELECT EXISTS(SELECT 1 FROM comments c JOIN posts p ON p.id = c.post_id
+			              WHERE c.id = ? AND `+publishedPost+`)`, targetID).Scan(&exists)
+	}
+	if err != nil || !exists {
+		RenderErrorPage(w, r, db, http.StatusNotFound, "Content not found")
+		return
+	}
+
+	// Resolve the list: an explicit new list name wins over a selected list.
+	var listID int
+	newList := strings.TrimSpace(r.FormValue("new_list"))
+	switch {
+	case newList != "":
+		listID, err = ensureBookmarkList(db, userID, newList, false)
+	case r.FormValue("list_id") != "":
+		listID, err = strconv.Atoi(r.FormValue("list_id"))
+		if err == nil {
+			// The list must belong to the current user.
+			var owned bool
+			err = db.QueryRow("SELECT EXISTS(SELECT 1 FROM bookmark_lists WHERE id = ? AND user_id = ?)",
+				listID, userID).Scan(&owned)
+			if err == nil && !owned {
+				RenderErrorPage(w, r, db, http.StatusNotFound, "Reading list not found")
+				return
+			}
+		}
+	default:
+		listID, err = ensureBookmarkList(db, userID, defaultBookmarkList, false)
+	}
+	if err != nil {
+		log.Printf("Error resolving bookmark list: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error saving bookmark")
+		return
+	}
+
+	_, err = db.Exec(`
+		INSERT OR IGNORE INTO bookmarks (list_id, target_type, target_id, created_at)
+		VALUES (?, ?, ?, ?)`, listID, targetType, targetID, time.Now())
+	if err != nil {
+		log.Printf("Error saving bookmark: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error saving bookmark")
+		return
+	}
+
+	http.Redirect(w, r, redirectTarget(r, "/user/bookmarks"), http.StatusSeeOther)
+}
+
+// RemoveBookmarkHandler removes a bookmark from one of the user's lists.
+func RemoveBookmarkHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests change state.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	userID, err := GetUserIDFromSession(r, db)
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusUnauthorized, "User is not authorised")
+		return
+	}
+
+	bookmarkID, err := strconv.Atoi(r.FormValue("bookmark_id"))
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Invalid bookmark ID")
+		return
+	}
+
+	// Only delete bookmarks that live in the user's own lists.
+	_, err = db.Exec(`
+		DELETE FROM bookmarks
+		WHERE id = ? AND list_id IN (SELECT id FROM bookmark_lists WHERE user_id = ?)`, bookmarkID, userID)
+	if err != nil {
+		log.Printf("Error removing bookmark: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error removing bookmark")
+		return
+	}
+
+	http.Redirect(w, r, "/user/bookmarks", http.StatusSeeOther)
+}
+
+// BookmarkListHandler creates a reading list, toggles its visibility or deletes it, depending on "action".
+func BookmarkListHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests change state.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	userID, err := GetUserIDFromSession(r, db)
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusUnauthorized, "User is not authorised")
+		return
+	}
+
+	switch r.FormValue("action") {
+	case "create":
+		name := strings.TrimSpace(r.FormValue("name"))
+		if name == "" {
+			http.Redirect(w, r, "/user/bookmarks?error="+url.QueryEscape("The list name cannot be empty"), http.StatusSeeOther)
+			return
+		}
+		if _, err := ensureBookmarkList(db, userID, name, r.FormValue("is_public") == "on"); err != nil {
+			log.Printf("Error creating bookmark list: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error creating list")
+			return
+		}
+	case "toggle_visibility", "delete":
+		listID, err := strconv.Atoi(r.FormValue("list_id"))
+		if err != nil {
+			RenderErrorPage(w, r, db, http.StatusBadRequest, "Invalid list ID")
+			return
+		}
+		if r.FormValue("action") == "delete" {
+			err = deleteBookmarkList(db, userID, listID)
+		} else {
+			_, err = db.Exec("UPDATE bookmark_lists SET is_public = NOT is_public WHERE id = ? AND user_id = ?", listID, userID)
+		}
+		if err != nil {
+			log.Printf("Error updating bookmark list: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error updating list")
+			return
+		}
+	default:
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Unknown action")
+		return
+	}
+
+	http.Redirect(w, r, "/user/bookmarks", http.StatusSeeOther)
+}
+
+// ensureBookmarkList returns the ID of the user's list with the given name, creating it if needed.
+func ensureBookmarkList(db *sql.DB, userID int, name string, isPublic bool) (int, error) {
+	_, err := db.Exec("INSERT OR IGNORE INTO bookmark_lists (user_id, name, is_public, created_at) VALUES (?, ?, ?, ?)",
+		userID, name, isPublic, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	var listID int
+	err = db.QueryRow("SELECT id FROM bookmark_lists WHERE user_id = ? AND name = ?", userID, name).Scan(&listID)
+	return listID, err
+}
+
+// deleteBookmarkList removes a list owned by the user together with its bookmarks.
+func deleteBookmarkList(db *sql.DB, userID, listID int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM bookmarks WHERE list_id IN (SELECT id FROM bookmark_lists WHERE id = ? AND user_id = ?)",
+		listID, userID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM bookmark_lists WHERE id = ? AND user_id = ?", listID, userID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// loadBookmarkLists returns the user's reading lists, optionally only the public ones.
+func loadBookmarkLists(db *sql.DB, userID int, publicOnly bool) ([]models.BookmarkList, error) {
+	query := "SELECT id, user_id, name, is_public, created_at FROM bookmark_lists WHERE user_id = ?"
+	if publicOnly {
+		query += " AND is_public = 1"
+	}
+	query += " ORDER BY name"
+
+	rows, err := db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lists []models.BookmarkList
+	for rows.Next() {
+		var list models.BookmarkList
+		if err := rows.Scan(&list.ID, &list.UserID, &list.Name, &list.IsPublic, &list.CreatedAt); err != nil {
+			return nil, err
+		}
+		lists = append(lists, list)
+	}
+	return lists, rows.Err()
+}
+
+// loadBookmarks returns the posts and comments saved in a list, newest first. Content of
+// posts that are not published yet is left out.
+func loadBookmarks(db *sql.DB, listID int) ([]models.Bookmark, error) {
+	rows, err := db.Query(`
+		SELECT b.id, b.list_id, b.target_type, b.target_id, b.created_at,
+		       COALESCE(p.id, cp.id, 0), COALESCE(p.title, cp.title, ''), COALESCE(p.body, c.body, '')
+		FROM bookmarks b
+		LEFT JOIN posts p ON b.target_type = 'post' AND p.id = b.target_id
+		LEFT JOIN comments c ON b.target_type = 'comment' AND c.id = b.target_id
+		LEFT JOIN posts cp ON cp.id = c.post_id
+		WHERE b.list_id = ? AND COALESCE(p.publish_at, cp.publish_at) IS NULL
+		ORDER BY b.created_at DESC`, listID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bookmarks []models.Bookmark
+	for rows.Next() {
+		var bookmark models.Bookmark
+		if err := rows.Scan(&bookmark.ID, &bookmark.ListID, &bookmark.TargetType, &bookmark.TargetID, &bookmark.CreatedAt,
+			&bookmark.PostID, &bookmark.Title, &bookmark.Excerpt); err != nil {
+			return nil, err
+		}
+		bookmark.Excerpt = truncate(bookmark.Excerpt, 150)
+		bookmarks = append(bookmarks, bookmark)
+	}
+	return bookmarks, rows.Err()
+}
@@ -18,7 +18,7 @@ func CategoriesHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 	}
 
 	// Retrieve all categories from the database, ordered by creation date in descending order
-	rows, err := db.Query("SELECT id, name, description, created_at FROM categories ORDER BY created_at DESC")
+	rows, err := db.Query("SELECT id, name, description, is_question, created_at FROM categories ORDER BY created_at DESC")
 	if err != nil { // Handle any database query errors
 		log.Printf("Error getting the category: %v", err)                                   // Log the error for debugging purposes
 		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading category") // Render error page
@@ -32,7 +32,7 @@ func CategoriesHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 	for rows.Next() {
 		var category models.Category // Create a variable to hold a single category's data
 		// Scan the current row's data into the category struct
-		if err := rows.Scan(&category.ID, &category.Name, &category.Description, &category.CreatedAt); err != nil {
+		if err := rows.Scan(&category.ID, &category.Name, &category.Description, &category.IsQuestion, &category.CreatedAt); err != nil {
 			log.Printf("Error reading category: %v", err)                                       // Log any scanning error
 			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading category") // Render error page
 			return
@@ -65,13 +65,41 @@ func CategoriesHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 		}
 	}
 
+	// Load the categories the user follows so the page can offer follow/unfollow buttons
+	followed := make(map[int]bool)
+	if user != nil {
+		followed, err = followedCategoryIDs(db, user.ID)
+		if err != nil { // A failure here only hides the follow state, so it is logged and ignored
+			log.Printf("Error loading followed categories: %v", err)
+			followed = make(map[int]bool)
+		}
+	}
+
+	// Count the posts with unread activity in each category
+	unread := make(map[int]int)
+	if user != nil {
+		unread, err = unreadByCategory(db, user.ID)
+		if err != nil { // Unread counts are optional, so a failure is logged and ignored
+			log.Printf("Error loading unread counts: %v", err)
+			unread = make(map[int]int)
+		}
+	}
+
 	// Structure to store the categories and user data to be passed to the template
 	pageData := struct {
 		Categories []models.Category // List of all categories
 		User       *models.User      // Logged-in user info; may be nil if no user is logged in
+		Followed   map[int]bool      // IDs of the categories the user follows
+		Unread     map[int]int       // Number of posts with unread activity per category ID
+		CanCreate  bool              // Whether the user's trust level allows creating categories
+		CanManage  bool              // Whether the user is a moderator who can switch Q&A mode
 	}{
-		Categories: categories, // Pass the retrieved categories
-		User:       user,       // Pass the user data (or nil)
+		Categories: categories,                                                  // Pass the retrieved categories
+		User:       user,                                                        // Pass the user data (or nil)
+		Followed:   followed,                                                    // Pass the followed category IDs
+		Unread:     unread,                                                      // Pass the unread counts
+		CanCreate:  user != nil && hasTrust(db, user.ID, trustToCreateCategory), // Offer the new category form
+		CanManage:  user != nil && isModerator(db, user.ID),                     // Offer the Q&A mode switch
 	}
 
 	// Parse the necessary HTML templates for rendering the page
@@ -93,3 +121,22 @@ func CategoriesHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 		return
 	}
 }
+
+// loadCategories fetches the ID and name of every category, as needed by the header's search form.
+func loadCategories(db *sql.DB) ([]models.Category, error) {
+	rows, err := db.Query("SELECT id, name FROM categories")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() // Ensure that the database rows are properly closed after use
+
+	var categories []models.Category
+	for rows.Next() {
+		var category models.Category
+		if err := rows.Scan(&category.ID, &category.Name); err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	return categories, rows.Err()
+}
@@ -8,6 +8,7 @@ import (
 
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -40,25 +41,53 @@ func CreateCommentHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 		return
 	}
 
-	// Retrieve the user ID from the session token stored in cookies.
-	var userID int
-	cookie, err := r.Cookie("session_token") // Retrieve the session token from the cookie.
-	if err == nil {
-		// Query the database to find the user ID associated with the session token.
-		err = db.QueryRow("SELECT user_id FROM sessions WHERE session_token = ?", cookie.Value).Scan(&userID)
-		if err != nil {
-			// If the session token is invalid, render an "Unauthorized" error page.
-			RenderErrorPage(w, r, db, http.StatusUnauthorized, "Authentication error")
+	// Retrieve the user ID from the session; suspended and banned users cannot comment.
+	userID, ok := requireActiveUser(w, r, db)
+	if !ok {
+		return
+	}
+
+	// Locked threads take no new comments, except from moderators.
+	locked, err := isThreadLocked(db, postID)
+	if err == sql.ErrNoRows {
+		RenderErrorPage(w, r, db, http.StatusNotFound, "Post not found")
+		return
+	} else if err != nil {
+		log.Printf("Error loading the post: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error when adding the comment")
+		return
+	}
+	if locked && !isModerator(db, userID) {
+		message := "This thread is locked and no longer takes comments."
+		http.Redirect(w, r, fmt.Sprintf("/post/%d?error=%s", postID, url.QueryEscape(message)), http.StatusSeeOther)
+		return
+	}
+
+	// Apply the word filters and spam heuristics before publishing.
+	_, body, screen, err := screenSubmission(db, userID, "", body)
+	if err != nil {
+		log.Printf("Error screening the comment: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error when adding the comment")
+		return
+	}
+	switch screen.Verdict {
+	case filterReject:
+		message := "Your comment was not published. " + screen.Reason + "."
+		http.Redirect(w, r, fmt.Sprintf("/post/%d?error=%s", postID, url.QueryEscape(message)), http.StatusSeeOther)
+		return
+	case filterHold:
+		if err := holdSubmission(db, userID, "comment", postID, 0, "", body, nil, nil, screen.Reason); err != nil {
+			log.Printf("Error holding the comment: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error when adding the comment")
 			return
 		}
-	} else {
-		// If no session token exists, render an "Unauthorized" error page.
-		RenderErrorPage(w, r, db, http.StatusUnauthorized, "User is not authorized")
+		message := "Your comment was sent to the moderators for review and will appear once approved."
+		http.Redirect(w, r, fmt.Sprintf("/post/%d?notice=%s", postID, url.QueryEscape(message)), http.StatusSeeOther)
 		return
 	}
 
 	// Insert the new comment into the "comments" table in the database.
-	_, err = db.Exec("INSERT INTO comments (post_id, user_id, body, created_at) VALUES (?, ?, ?, ?)", postID, userID, body, time.Now())
+	result, err := db.Exec("INSERT INTO comments (post_id, user_id, body, created_at) VALUES (?, ?, ?, ?)", postID, userID, body, time.Now())
 	if err != nil {
 		// Log the error and render an "Internal Server Error" page if the insertion fails.
 		log.Printf("Error when adding the comment: %v", err)
@@ -66,10 +95,52 @@ func CreateCommentHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 		return
 	}
 
+	// Comments by shadowbanned users are visible only to themselves, so nobody else hears about
+	// them and they earn no badges.
+	if isShadowbanned(db, userID) {
+		http.Redirect(w, r, fmt.Sprintf("/post/%d", postID), http.StatusSeeOther)
+		return
+	}
+
+	// Award any badges the new comment earns.
+	awardBadges(db, userID, badgeEventComment)
+
+	// Push the new comment to everyone currently viewing the post and record any @mentions in it.
+	if commentID, err := result.LastInsertId(); err == nil {
+		publishComment(db, int(commentID))
+		recordMentions(db, userID, "comment", int(commentID), postID, body)
+	}
+
+	// Let the post's author know somebody else commented on their post.
+	notifyPostAuthorOfComment(db, postID, userID)
+
 	// Redirect the user back to the post page after successfully adding the comment.
 	http.Redirect(w, r, fmt.Sprintf("/post/%d", postID), http.StatusSeeOther)
 }
 
+// notifyPostAuthorOfComment creates a notification for the author of the post about a new comment,
+// unless the author commented on their own post.
+func notifyPostAuthorOfComment(db *sql.DB, postID, commenterID int) {
+	var authorID int
+	var title, commenter string
+	err := db.QueryRow(`
+		SELECT p.user_id, p.title, u.username
+		FROM posts p, users u
+		WHERE p.id = ? AND u.id = ?`, postID, commenterID).Scan(&authorID, &title, &commenter)
+	if err != nil {
+		log.Printf("Error loading post author for notification: %v", err)
+		return
+	}
+	if authorID == commenterID {
+		return
+	}
+
+	message := fmt.Sprintf("%s commented on your post \"%s\"", commenter, title)
+	if err := CreateNotification(db, authorID, "comment", message, fmt.Sprintf("/post/%d", postID)); err != nil {
+		log.Printf("Error creating comment notification: %v", err)
+	}
+}
+
 func UserCommentsHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 	// Ensure the HTTP method is GET; if not, return a 405 Method Not Allowed error
 	if r.Method != http.MethodGet {
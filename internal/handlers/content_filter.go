@@ -0,0 +1,460 @@
+package handlers
+
+import (
+	"database/sql"                          // Provides SQL database support
+	"fmt"                                   // Used to format reasons and notifications
+	"html/template"                         // Used for rendering HTML templates
+	models "literary-lions/internal/models" // Imports the models package for structured data types
+	"log"                                   // Used for logging errors
+	"net/http"                              // Provides HTTP client and server implementations
+	"net/url"                               // Used to escape error messages in redirects
+	"regexp"                                // Used to match filter patterns and links
+	"strings"                               // Used to normalise submissions
+	"time"                                  // Used for the duplicate window and review timestamps
+)
+
+// Word filter policies stored in "word_filters.policy".
+const (
+	filterReplace = "replace" // Matches are replaced with the filter's replacement text
+	filterHold    = "hold"    // The submission is held for moderator review
+	filterReject  = "reject"  // The submission is refused
+)
+
+// Spam heuristics applied to every new post and comment.
+const (
+	maxLinksPerSubmission = 3              // More links than this sends a submission to review
+	duplicateMinLength    = 20             // Shorter texts ("Thanks!") are not checked for duplicates
+	duplicateWindow       = 24 * time.Hour // Repeating the same text within this window sends it to review
+)
+
+// linkPattern matches the start of a link in submitted text.
+var linkPattern = regexp.MustCompile(`(?i)https?://|www\.`)
+
+// screening is the outcome of checking a submission against the filters and heuristics.
+type screening struct {
+	Verdict string // "" to publish, filterHold or filterReject
+	Reason  string // Why the submission was held or rejected
+}
+
+// compileFilter turns a filter pattern into a case-insensitive regular expression.
+// Plain words only match whole words.
+func compileFilter(pattern string, isRegex bool) (*regexp.Regexp, error) {
+	if !isRegex {
+		pattern = `\b` + regexp.QuoteMeta(pattern) + `\b`
+	}
+	return regexp.Compile("(?i)" + pattern)
+}
+
+// screenSubmission runs the word filters and spam heuristics over a new post or comment.
+// It returns the title and body with replacements applied, and the verdict. title is empty for comments.
+func screenSubmission(db *sql.DB, userID int, title, body string) (string, string, screening, error) {
+	filters, err := loadWordFilters(db)
+	if err != nil {
+		return title, body, screening{}, err
+	}
+
+	var result screening
+	for _, filter := range filters {
+		re, err := compileFilter(filter.Pattern, filter.IsRegex)
+		if err != nil {
+			log.Printf("Skipping invalid word filter %d: %v", filter.ID, err)
+			continue
+		}
+		if !re.MatchString(title) && !re.MatchString(body) {
+			continue
+		}
+		switch filter.Policy {
+		case filterReplace:
+			title = re.ReplaceAllLiteralString(title, filter.Replacement)
+			body = re.ReplaceAllLiteralString(body, filter.Replacement)
+		case filterReject:
+			return title, body, screening{Verdict: filterReject, Reason: "It contains a blocked word"}, nil
+		case filterHold:
+			if result.Verdict == "" {
+				result = screening{Verdict: filterHold, Reason: fmt.Sprintf("Matched word filter %q", filter.Pattern)}
+			}
+		}
+	}
+	if result.Verdict != "" {
+		return title, body, result, nil
+	}
+
+	// Links from new members wait for a moderator instead of going out.
+	links := len(linkPattern.FindAllStringIndex(title+" "+body, -1))
+	if links > 0 && !hasTrust(db, userID, trustToPostLinks) {
+		return title, body, screening{Verdict: filterHold, Reason: "Links from new members are reviewed first"}, nil
+	}
+	if links > maxLinksPerSubmission {
+		return title, body, screening{Verdict: filterHold, Reason: fmt.Sprintf("Contains %d links", links)}, nil
+	}
+
+	duplicate, err := isDuplicateSubmission(db, userID, body)
+	if err != nil {
+		return title, body, screening{}, err
+	}
+	if duplicate {
+		return title, body, screening{Verdict: filterHold, Reason: "Repeats a recent post or comment"}, nil
+	}
+
+	score, trained, err := spamScore(db, title+" "+body)
+	if err != nil {
+		return title, body, screening{}, err
+	}
+	if trained && score > spamThreshold {
+		return title, body, screening{Verdict: filterHold, Reason: fmt.Sprintf("Spam score %.2f", score)}, nil
+	}
+	return title, body, screening{}, nil
+}
+
+// isDuplicateSubmission reports whether the user already posted the same text within duplicateWindow.
+func isDuplicateSubmission(db *sql.DB, userID int, body string) (bool, error) {
+	body = strings.TrimSpace(body)
+	if len(body) < duplicateMinLength {
+		return false, nil
+	}
+
+	since := time.Now().Add(-duplicateWindow)
+	var duplicate bool
+	err := db.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM posts WHERE user_id = ? AND body = ? AND created_at > ?)
+		    OR EXISTS(SELECT 1 FROM comments WHERE user_id = ? AND body = ? AND created_at > ?)`,
+		userID, body, since, userID, body, since).Scan(&duplicate)
+	return duplicate, err
+}
+
+// loadWordFilters returns every configured filter, newest first.
+func loadWordFilters(db *sql.DB) ([]models.WordFilter, error) {
+	rows, err := db.Query(`
+		SELECT f.id, f.pattern, f.is_regex, f.policy, COALESCE(f.replacement, ''), f.created_at, u.username
+		FROM word_filters f
+		JOIN users u ON u.id = f.created_by
+		ORDER BY f.id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var filters []models.WordFilter
+	for rows.Next() {
+		var filter models.WordFilter
+		if err := rows.Scan(&filter.ID, &filter.Pattern, &filter.IsRegex, &filter.Policy, &filter.Replacement,
+			&filter.CreatedAt, &filter.CreatedBy); err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	return filters, rows.Err()
+}
+
+// holdSubmission stores a post (postID 0) or a comment (categoryID 0, empty title, no poll and
+// no publishing date) for moderator review.
+func holdSubmission(db *sql.DB, userID int, targetType string, postID, categoryID int, title, body string, poll *pollSpec, publishAt *time.Time, reason string) error {
+	var post, category, heldTitle, heldPublishAt interface{}
+	if targetType == "comment" {
+		post = postID
+	} else {
+		category, heldTitle = categoryID, title
+	}
+	if publishAt != nil {
+		heldPublishAt = *publishAt
+	}
+	heldPoll, err := encodePoll(poll)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+		INSERT INTO held_submissions (user_id, target_type, post_id, category_id, title, body, poll, publish_at, reason, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		userID, targetType, post, category, heldTitle, body, heldPoll, heldPublishAt, reason, time.Now())
+	return err
+}
+
+// loadHeldSubmissions returns the submissions waiting for review, oldest first.
+func loadHeldSubmissions(db *sql.DB) ([]models.HeldSubmission, error) {
+	rows, err := db.Query(`
+		SELECT h.id, h.user_id, u.username, h.target_type, COALESCE(h.post_id, 0), COALESCE(h.category_id, 0),
+		       COALESCE(h.title, ''), h.body, h.reason, h.status, h.created_at, COALESCE(p.title, '')
+		FROM held_submissions h
+		JOIN users u ON u.id = h.user_id
+		LEFT JOIN posts p ON p.id = h.post_id
+		WHERE h.status = 'pending'
+		ORDER BY h.created_at ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var held []models.HeldSubmission
+	for rows.Next() {
+		var submission models.HeldSubmission
+		if err := rows.Scan(&submission.ID, &submission.UserID, &submission.Username, &submission.TargetType,
+			&submission.PostID, &submission.CategoryID, &submission.Title, &submission.Body, &submission.Reason,
+			&submission.Status, &submission.CreatedAt, &submission.PostTitle); err != nil {
+			return nil, err
+		}
+		held = append(held, submission)
+	}
+	return held, rows.Err()
+}
+
+// WordFiltersHandler lists the word filters on GET and adds ("add") or removes ("delete") one on POST.
+// Only admins can manage filters.
+func WordFiltersHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	user, ok := requireModerator(w, r, db)
+	if !ok {
+		return
+	}
+	if user.Role != "admin" {
+		RenderErrorPage(w, r, db, http.StatusForbidden, "Only admins can manage word filters")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		filters, err := loadWordFilters(db)
+		if err != nil {
+			log.Printf("Error loading word filters: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading word filters")
+			return
+		}
+
+		categories, err := loadCategories(db)
+		if err != nil {
+			log.Printf("Error loading categories: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading categories")
+			return
+		}
+
+		pageData := models.WordFiltersPageData{
+			User:         user,
+			Filters:      filters,
+			Categories:   categories,
+			ErrorMessage: r.URL.Query().Get("error"),
+		}
+
+		tmpl, err := template.ParseFiles("assets/template/header.html", "assets/template/word_filters.html")
+		if err != nil {
+			log.Printf("Error loading template: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading template")
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html")
+		if err := tmpl.ExecuteTemplate(w, "word_filters", pageData); err != nil {
+			log.Printf("Rendering error: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Rendering page error")
+		}
+
+	case http.MethodPost:
+		var err error
+		switch r.FormValue("action") {
+		case "add":
+			pattern := strings.TrimSpace(r.FormValue("pattern"))
+			policy := r.FormValue("policy")
+			isRegex := r.FormValue("is_regex") == "on"
+			if pattern == "" {
+				filterError(w, r, "The pattern cannot be empty")
+				return
+			}
+			if policy != filterReplace && policy != filterHold && policy != filterReject {
+				filterError(w, r, "Unknown policy")
+				return
+			}
+			if _, err := compileFilter(pattern, isRegex); err != nil {
+				filterError(w, r, "Invalid regular expression: "+err.Error())
+				return
+			}
+			_, err = db.Exec(`
+				INSERT INTO word_filters (pattern, is_regex, policy, replacement, created_by, created_at)
+				VALUES (?, ?, ?, ?, ?, ?)`,
+				pattern, isRegex, policy, r.FormValue("replacement"), user.ID, time.Now())
+		case "delete":
+			_, err = db.Exec("DELETE FROM word_filters WHERE id = ?", r.FormValue("filter_id"))
+		default:
+			RenderErrorPage(w, r, db, http.StatusBadRequest, "Unknown action")
+			return
+		}
+		if err != nil {
+			log.Printf("Error saving word filter: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error saving word filter")
+			return
+		}
+		http.Redirect(w, r, "/moderation/filters", http.StatusSeeOther)
+
+	default:
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+	}
+}
+
+// HeldSubmissionHandler lets a moderator "approve" a held post or comment, which publishes it,
+// "reject" it, or reject it as "spam". Approvals and spam verdicts train the spam classifier.
+func HeldSubmissionHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests change state.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	moderator, ok := requireModerator(w, r, db)
+	if !ok {
+		return
+	}
+
+	action := r.FormValue("action")
+	if action != "approve" && action != "reject" && action != "spam" {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Unknown action")
+		return
+	}
+
+	var held models.HeldSubmission
+	var heldPoll string
+	var heldPublishAt sql.NullTime
+	err := db.QueryRow(`
+		SELECT id, user_id, target_type, COALESCE(post_id, 0), COALESCE(category_id, 0), COALESCE(title, ''), body,
+		       COALESCE(poll, ''), publish_at
+		FROM held_submissions WHERE id = ? AND status = 'pending'`, r.FormValue("held_id")).
+		Scan(&held.ID, &held.UserID, &held.TargetType, &held.PostID, &held.CategoryID, &held.Title, &held.Body, &heldPoll, &heldPublishAt)
+	if err == sql.ErrNoRows {
+		moderationError(w, r, "The submission was already reviewed")
+		return
+	} else if err != nil {
+		log.Printf("Error loading held submission: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	// Content of authors shadowbanned while it was held is published as if they had posted it
+	// directly: visible only to themselves, without mentions or live updates.
+	shadowbanned := isShadowbanned(db, held.UserID)
+	authorIsModerator := isModerator(db, held.UserID)
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error reviewing submission")
+		return
+	}
+	defer tx.Rollback()
+
+	// The thread of a held comment may have changed while it waited. A comment on a merged thread
+	// follows it to the thread it was merged into; one on a post that was deleted, unpublished or
+	// locked since gets the same answer it would get from CreateCommentHandler.
+	if action == "approve" && held.TargetType == "comment" {
+		var mergedID int
+		err := tx.QueryRow(`
+			SELECT to_post_id FROM post_redirects WHERE from_post_id = ? AND comment_id IS NULL
+			ORDER BY id DESC LIMIT 1`, held.PostID).Scan(&mergedID)
+		if err == nil {
+			held.PostID = mergedID
+		} else if err != sql.ErrNoRows {
+			log.Printf("Error loading post redirect: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error reviewing submission")
+			return
+		}
+
+		var locked bool
+		err = tx.QueryRow("SELECT locked FROM posts WHERE id = ? AND publish_at IS NULL", held.PostID).Scan(&locked)
+		if err == sql.ErrNoRows {
+			moderationError(w, r, "The post of this comment no longer exists; reject the comment instead")
+			return
+		} else if err != nil {
+			log.Printf("Error loading the post: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error reviewing submission")
+			return
+		}
+		if locked && !authorIsModerator {
+			moderationError(w, r, "The thread was locked after the comment was held; reject the comment instead")
+			return
+		}
+	}
+
+	// A held post approved before its publishing date stays scheduled; one approved later goes out at once.
+	var publishAt interface{}
+	scheduled := heldPublishAt.Valid && heldPublishAt.Time.After(time.Now())
+	if scheduled {
+		publishAt = heldPublishAt.Time
+	}
+
+	status, targetType, targetID := "rejected", "submission", held.ID
+	if action == "approve" {
+		status, targetType = "approved", held.TargetType
+		var result sql.Result
+		if held.TargetType == "comment" {
+			result, err = tx.Exec("INSERT INTO comments (post_id, user_id, body, created_at) VALUES (?, ?, ?, ?)",
+				held.PostID, held.UserID, held.Body, time.Now())
+		} else {
+			result, err = tx.Exec("INSERT INTO posts (user_id, title, body, category_id, created_at, publish_at) VALUES (?, ?, ?, ?, ?, ?)",
+				held.UserID, held.Title, held.Body, held.CategoryID, time.Now(), publishAt)
+		}
+		if err == nil {
+			var id int64
+			id, err = result.LastInsertId()
+			targetID = int(id)
+		}
+		// A held post is published together with its poll.
+		if err == nil && held.TargetType == "post" {
+			var poll *pollSpec
+			poll, err = decodePoll(heldPoll)
+			if err == nil && poll != nil {
+				err = createPoll(tx, targetID, poll)
+			}
+		}
+	}
+	if err == nil && action != "reject" {
+		label := labelHam
+		if action == "spam" {
+			label = labelSpam
+		}
+		err = trainSpamExample(tx, label, strings.TrimSpace(held.Title+" "+held.Body), moderator.ID)
+	}
+	if err == nil {
+		_, err = tx.Exec("UPDATE held_submissions SET status = ?, resolved_by = ?, resolved_at = ? WHERE id = ?",
+			status, moderator.ID, time.Now(), held.ID)
+	}
+	if err == nil {
+		err = logModeration(tx, moderator.ID, action, targetType, targetID, 0, r.FormValue("note"))
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("Error reviewing held submission: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error reviewing submission")
+		return
+	}
+
+	// Approved content goes through the same follow-up as if it had been published directly.
+	link := "/user"
+	if action == "approve" {
+		if held.TargetType == "comment" {
+			if !shadowbanned {
+				publishComment(db, targetID)
+				recordMentions(db, held.UserID, "comment", targetID, held.PostID, held.Body)
+				notifyPostAuthorOfComment(db, held.PostID, held.UserID)
+				awardBadges(db, held.UserID, badgeEventComment)
+			}
+			link = fmt.Sprintf("/post/%d", held.PostID)
+		} else {
+			// Scheduled posts get their follow-up when they are published.
+			if !scheduled {
+				if !shadowbanned {
+					recordMentions(db, held.UserID, "post", targetID, targetID, held.Body)
+				}
+				awardBadges(db, held.UserID, badgeEventPost)
+			}
+			link = fmt.Sprintf("/post/%d", targetID)
+		}
+	}
+
+	message := fmt.Sprintf("Your %s was %s by the moderators", held.TargetType, status)
+	if err := CreateNotification(db, held.UserID, "moderation", message, link); err != nil {
+		log.Printf("Error creating moderation notification: %v", err)
+	}
+
+	http.Redirect(w, r, "/moderation", http.StatusSeeOther)
+}
+
+// filterError sends the admin back to the word filter page with an error message.
+func filterError(w http.ResponseWriter, r *http.Request, message string) {
+	http.Redirect(w, r, "/moderation/filters?error="+url.QueryEscape(message), http.StatusSeeOther)
+}
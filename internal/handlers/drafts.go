@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"database/sql"                          // Provides SQL database support
+	"encoding/json"                         // Used to answer the autosave script
+	"fmt"                                   // Used to build redirect paths
+	models "literary-lions/internal/models" // Imports the models package for structured data types
+	"log"                                   // Used for logging errors
+	"net/http"                              // Provides HTTP client and server implementations
+	"strconv"                               // Used to parse draft and category IDs
+	"strings"                               // Used to trim the title
+	"time"                                  // Used to timestamp saves
+)
+
+// SaveDraftHandler saves the new post form as a draft of the logged-in user. Without a "draft_id"
+// it starts a new draft, with one it overwrites that draft. The autosave script gets the draft's ID
+// back as JSON; the "Save draft" button is sent back to the form.
+func SaveDraftHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests change state.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	userID, err := GetUserIDFromSession(r, db)
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusUnauthorized, "User is not authorised")
+		return
+	}
+
+	title := strings.TrimSpace(r.FormValue("title"))
+	body := r.FormValue("body")
+	if title == "" && strings.TrimSpace(body) == "" {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "There is nothing to save yet")
+		return
+	}
+	var categoryID interface{}
+	if value := r.FormValue("category_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			RenderErrorPage(w, r, db, http.StatusBadRequest, "Incorrect ID of category")
+			return
+		}
+		categoryID = id
+	}
+
+	now := time.Now()
+	var draftID int
+	if value := r.FormValue("draft_id"); value != "" {
+		draftID, err = strconv.Atoi(value)
+		if err != nil {
+			RenderErrorPage(w, r, db, http.StatusBadRequest, "Incorrect ID of the draft")
+			return
+		}
+		result, err := db.Exec(`
+			UPDATE drafts SET title = ?, body = ?, category_id = ?, updated_at = ?
+			WHERE id = ? AND user_id = ?`, title, body, categoryID, now, draftID, userID)
+		if err != nil {
+			log.Printf("Error saving the draft: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error saving the draft")
+			return
+		}
+		if updated, _ := result.RowsAffected(); updated == 0 {
+			RenderErrorPage(w, r, db, http.StatusNotFound, "Draft not found")
+			return
+		}
+	} else {
+		result, err := db.Exec(`
+			INSERT INTO drafts (user_id, title, body, category_id, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?)`, userID, title, body, categoryID, now, now)
+		if err == nil {
+			var id int64
+			id, err = result.LastInsertId()
+			draftID = int(id)
+		}
+		if err != nil {
+			log.Printf("Error saving the draft: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error saving the draft")
+			return
+		}
+	}
+
+	if wantsJSON(r) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":       draftID,
+			"saved_at": now.Format("15:04"),
+		})
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/new-post?draft=%d", draftID), http.StatusSeeOther)
+}
+
+// DeleteDraftHandler throws away a draft of the logged-in user.
+func DeleteDraftHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests change state.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	userID, err := GetUserIDFromSession(r, db)
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusUnauthorized, "User is not authorised")
+		return
+	}
+
+	draftID, err := strconv.Atoi(r.FormValue("draft_id"))
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Incorrect ID of the draft")
+		return
+	}
+	if _, err := db.Exec("DELETE FROM drafts WHERE id = ? AND user_id = ?", draftID, userID); err != nil {
+		log.Printf("Error deleting the draft: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error deleting the draft")
+		return
+	}
+
+	http.Redirect(w, r, "/user", http.StatusSeeOther)
+}
+
+// loadDraft returns a draft of the given user, or nil if there is no such draft.
+func loadDraft(db *sql.DB, draftID, userID int) (*models.Draft, error) {
+	draft := &models.Draft{}
+	err := db.QueryRow(`
+		SELECT id, user_id, title, body, COALESCE(category_id, 0), updated_at
+		FROM drafts WHERE id = ? AND user_id = ?`, draftID, userID).
+		Scan(&draft.ID, &draft.UserID, &draft.Title, &draft.Body, &draft.CategoryID, &draft.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return draft, err
+}
+
+// loadDrafts returns the drafts of a user, most recently saved first.
+func loadDrafts(db *sql.DB, userID int) ([]models.Draft, error) {
+	rows, err := db.Query(`
+		SELECT id, user_id, title, body, COALESCE(category_id, 0), updated_at
+		FROM drafts WHERE user_id = ?
+		ORDER BY updated_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var drafts []models.Draft
+	for rows.Next() {
+		var draft models.Draft
+		if err := rows.Scan(&draft.ID, &draft.UserID, &draft.Title, &draft.Body, &draft.CategoryID, &draft.UpdatedAt); err != nil {
+			return nil, err
+		}
+		drafts = append(drafts, draft)
+	}
+	return drafts, rows.Err()
+}
+
+// discardDraft deletes the draft a post was published from, if the form named one.
+// The post is out either way, so failures are only logged.
+func discardDraft(db *sql.DB, r *http.Request, userID int) {
+	draftID, err := strconv.Atoi(r.FormValue("draft_id"))
+	if err != nil {
+		return
+	}
+	if _, err := db.Exec("DELETE FROM drafts WHERE id = ? AND user_id = ?", draftID, userID); err != nil {
+		log.Printf("Error discarding the draft: %v", err)
+	}
+}
@@ -0,0 +1,339 @@
+package handlers
+
+import (
+	"database/sql"                          // Provides SQL database support
+	"encoding/json"                         // Used to encode event payloads as JSON
+	"fmt"                                   // Used to format topic names and SSE frames
+	"html/template"                         // Used to render streamed comments
+	models "literary-lions/internal/models" // Imports the models package for structured data types
+	"log"                                   // Used for logging errors
+	"net/http"                              // Provides HTTP client and server implementations
+	"strconv"                               // Used to parse post IDs and Last-Event-ID values
+	"strings"                               // Used to split the request path and collect rendered comments
+	"sync"                                  // Guards the hub's subscriber and history maps
+	"time"                                  // Used for the heartbeat ticker
+)
+
+// historySize is the number of recent events kept per topic so reconnecting clients can catch up.
+const historySize = 100
+
+// historyTTL is how long the history of a topic nobody listens to is kept after its last event.
+// Clients reconnecting later than that start afresh.
+const historyTTL = 10 * time.Minute
+
+// heartbeatInterval is how often an SSE comment is written to keep idle connections alive.
+const heartbeatInterval = 15 * time.Second
+
+// Event is a single message delivered to Server-Sent Events subscribers.
+type Event struct {
+	ID   int64  // Monotonic event ID, sent as the SSE "id" field
+	Type string // Event name, sent as the SSE "event" field
+	Data string // JSON-encoded payload, sent as the SSE "data" field
+
+	publishedAt time.Time // When the event was published, used to expire idle topics
+}
+
+// Hub is an in-process publish/subscribe hub that fans events out to SSE connections.
+type Hub struct {
+	mu          sync.Mutex                         // Protects all fields below
+	nextID      int64                              // ID assigned to the next published event
+	subscribers map[string]map[chan Event]struct{} // Active subscriber channels per topic
+	history     map[string][]Event                 // Most recent events per topic, oldest first
+	lastSweep   time.Time                          // When idle topics were last expired
+}
+
+// NewHub creates an empty hub ready to accept subscribers.
+func NewHub() *Hub {
+	return &Hub{
+		nextID:      1,
+		subscribers: make(map[string]map[chan Event]struct{}),
+		history:     make(map[string][]Event),
+	}
+}
+
+// Events is the hub shared by the write handlers and the SSE endpoints.
+var Events = NewHub()
+
+// PostTopic returns the topic name used for events about a single post.
+func PostTopic(postID int) string {
+	return fmt.Sprintf("post:%d", postID)
+}
+
+// UserTopic returns the topic name used for events addressed to a single user.
+func UserTopic(userID int) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+// Subscribe registers a new subscriber on the topic and returns its channel together with
+// every buffered event newer than lastEventID, so a reconnecting client does not miss anything.
+func (h *Hub) Subscribe(topic string, lastEventID int64) (chan Event, []Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Buffer the channel so a slow client does not block publishers.
+	ch := make(chan Event, 16)
+	if h.subscribers[topic] == nil {
+		h.subscribers[topic] = make(map[chan Event]struct{})
+	}
+	h.subscribers[topic][ch] = struct{}{}
+
+	// Collect the events the client has not seen yet.
+	var missed []Event
+	if lastEventID > 0 {
+		for _, event := range h.history[topic] {
+			if event.ID > lastEventID {
+				missed = append(missed, event)
+			}
+		}
+	}
+	return ch, missed
+}
+
+// Unsubscribe removes the subscriber channel from the topic and closes it.
+func (h *Hub) Unsubscribe(topic string, ch chan Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if subs, ok := h.subscribers[topic]; ok {
+		if _, ok := subs[ch]; ok {
+			delete(subs, ch)
+			close(ch)
+		}
+		// Drop the topic entirely once nobody listens to it.
+		if len(subs) == 0 {
+			delete(h.subscribers, topic)
+		}
+	}
+}
+
+// Publish encodes the payload as JSON and delivers it to every subscriber of the topic.
+// Subscribers whose buffer is full miss the event; they can catch up through Last-Event-ID.
+func (h *Hub) Publish(topic, eventType string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Error encoding %s event for %s: %v", eventType, topic, err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	event := Event{ID: h.nextID, Type: eventType, Data: string(data), publishedAt: now}
+	h.nextID++
+	h.expireIdleTopics(now)
+
+	// Keep only the newest historySize events for replay.
+	history := append(h.history[topic], event)
+	if len(history) > historySize {
+		history = history[len(history)-historySize:]
+	}
+	h.history[topic] = history
+
+	for ch := range h.subscribers[topic] {
+		select {
+		case ch <- event:
+		default:
+			// Never block a publisher on a slow reader.
+		}
+	}
+}
+
+// PostEventsHandler streams new comments and reaction count changes for "/events/post/{id}".
+func PostEventsHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only GET requests can open an event stream.
+	if r.Method != http.MethodGet {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	// Extract the post ID from the URL path.
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[1] != "events" || pathParts[2] != "post" {
+		RenderErrorPage(w, r, db, http.StatusNotFound, "Page not found")
+		return
+	}
+	postID, err := strconv.Atoi(pathParts[3])
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Incorrect ID of the post")
+		return
+	}
+
+	// Make sure the post exists, and is not a scheduled post the viewer cannot preview,
+	// before holding a connection open for it.
+	visible, err := postVisibleTo(db, postID, viewerID(r, db))
+	if err != nil || !visible {
+		RenderErrorPage(w, r, db, http.StatusNotFound, "Post not found")
+		return
+	}
+
+	serveEvents(w, r, db, PostTopic(postID))
+}
+
+// UserEventsHandler streams notifications addressed to the logged-in user on "/events/user".
+func UserEventsHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only GET requests can open an event stream.
+	if r.Method != http.MethodGet {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	// The user stream is private, so a valid session is required.
+	userID, err := GetUserIDFromSession(r, db)
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusUnauthorized, "User is not authorised")
+		return
+	}
+
+	serveEvents(w, r, db, UserTopic(userID))
+}
+
+// expireIdleTopics drops the history of topics without subscribers whose last event is older
+// than historyTTL, so a topic per post and per user does not stay in memory for the life of
+// the process. It walks the topics at most once per historyTTL. The caller holds h.mu.
+func (h *Hub) expireIdleTopics(now time.Time) {
+	if now.Sub(h.lastSweep) < historyTTL {
+		return
+	}
+	h.lastSweep = now
+	for topic, history := range h.history {
+		if len(h.subscribers[topic]) > 0 {
+			continue
+		}
+		if now.Sub(history[len(history)-1].publishedAt) >= historyTTL {
+			delete(h.history, topic)
+		}
+	}
+}
+
+// serveEvents writes the SSE stream for a topic until the client disconnects.
+func serveEvents(w http.ResponseWriter, r *http.Request, db *sql.DB, topic string) {
+	// Streaming requires a writer that can flush partial responses.
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Streaming is not supported")
+		return
+	}
+
+	// Browsers send Last-Event-ID automatically on reconnect; the query parameter covers manual reconnects.
+	lastEventID, _ := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64)
+	if lastEventID == 0 {
+		lastEventID, _ = strconv.ParseInt(r.URL.Query().Get("last_event_id"), 10, 64)
+	}
+
+	ch, missed := Events.Subscribe(topic, lastEventID)
+	defer Events.Unsubscribe(topic, ch)
+
+	// Set the headers required for an event stream.
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	// Tell the browser how long to wait before reconnecting after a dropped connection.
+	fmt.Fprint(w, "retry: 3000\n\n")
+
+	// Replay anything the client missed while it was disconnected.
+	for _, event := range missed {
+		writeEvent(w, event)
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			// The client went away.
+			return
+		case event, ok := <-ch:
+			if !ok {
+				return
+			}
+			writeEvent(w, event)
+			flusher.Flush()
+		case <-heartbeat.C:
+			// SSE comments are ignored by the browser but keep proxies from closing the connection.
+			fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent writes a single event in the text/event-stream format.
+func writeEvent(w http.ResponseWriter, event Event) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+}
+
+// publishComment announces a newly created comment to everyone watching the post. The comment
+// is rendered with the post page's template, mention links and reaction buttons included, so
+// pages can insert it as is. Comments hidden by reports are not streamed.
+func publishComment(db *sql.DB, commentID int) {
+	var comment models.Comment
+	err := db.QueryRow(`
+		SELECT c.id, c.post_id, c.user_id, u.username, c.body, c.created_at
+		FROM comments c
+		JOIN users u ON c.user_id = u.id
+		WHERE c.id = ? AND c.hidden = 0`, commentID).Scan(
+		&comment.ID, &comment.PostID, &comment.UserID, &comment.Username, &comment.Body, &comment.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return
+	} else if err != nil {
+		log.Printf("Error loading comment %d for publishing: %v", commentID, err)
+		return
+	}
+
+	reputation, err := reputationOf(db, comment.UserID)
+	if err != nil {
+		log.Printf("Error loading reputation for publishing: %v", err)
+	}
+	tmpl, err := template.New("post").Funcs(template.FuncMap{"mentions": mentionLinker(db)}).
+		ParseFiles("assets/template/header.html", "assets/template/post.html")
+	if err != nil {
+		log.Printf("Error loading template for publishing: %v", err)
+		return
+	}
+	var html strings.Builder
+	err = tmpl.ExecuteTemplate(&html, "live_comment", models.CommentFragmentData{
+		Comment:    comment,
+		Reputation: reputation,
+		Reactions:  newReactionCounts(),
+	})
+	if err != nil {
+		log.Printf("Error rendering comment %d for publishing: %v", commentID, err)
+		return
+	}
+
+	Events.Publish(PostTopic(comment.PostID), "comment", map[string]interface{}{
+		"id":      comment.ID,
+		"post_id": comment.PostID,
+		"user_id": comment.UserID,
+		"html":    html.String(),
+	})
+}
+
+// publishReactionCounts announces the current reaction counts of a post or comment
+// to everyone watching the post it belongs to.
+func publishReactionCounts(db *sql.DB, targetID int, targetType string) {
+	// Comments are streamed on their parent post's topic.
+	postID := targetID
+	if targetType == "comment" {
+		if err := db.QueryRow("SELECT post_id FROM comments WHERE id = ?", targetID).Scan(&postID); err != nil {
+			log.Printf("Error finding the post of comment %d: %v", targetID, err)
+			return
+		}
+	}
+
+	counts, err := CountReactions(db, targetID, targetType)
+	if err != nil {
+		log.Printf("Error counting reactions for publishing: %v", err)
+		return
+	}
+
+	Events.Publish(PostTopic(postID), "reaction", map[string]interface{}{
+		"target_id":   targetID,
+		"target_type": targetType,
+		"counts":      counts,
+	})
+}
@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"database/sql"                          // Provides SQL database support
+	"fmt"                                   // Used to format notification messages and links
+	"html/template"                         // Used for rendering HTML templates
+	models "literary-lions/internal/models" // Imports the models package for structured data types
+	"log"                                   // Used for logging errors
+	"net/http"                              // Provides HTTP client and server implementations
+	"net/url"                               // Used to escape usernames in profile links and check redirect targets
+	"strconv"                               // Used to parse user and category IDs
+	"strings"                               // Used to validate redirect targets
+	"time"                                  // Used to timestamp follows
+)
+
+// feedLimit is the number of posts shown on the "/feed" page.
+const feedLimit = 50
+
+// FollowUserHandler follows or, if already followed, unfollows the user given by the "user_id" form value.
+func FollowUserHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests change state.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	userID, err := GetUserIDFromSession(r, db)
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusUnauthorized, "User is not authorised")
+		return
+	}
+
+	followedID, err := strconv.Atoi(r.FormValue("user_id"))
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+	if followedID == userID {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "You cannot follow yourself")
+		return
+	}
+
+	var followedName string
+	err = db.QueryRow("SELECT username FROM users WHERE id = ?", followedID).Scan(&followedName)
+	if err == sql.ErrNoRows {
+		RenderErrorPage(w, r, db, http.StatusNotFound, "User is not found")
+		return
+	} else if err != nil {
+		log.Printf("Error getting the user to follow: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	following, err := isFollowingUser(db, userID, followedID)
+	if err != nil {
+		log.Printf("Error checking follow: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	if following {
+		_, err = db.Exec("DELETE FROM user_follows WHERE follower_id = ? AND followed_id = ?", userID, followedID)
+	} else {
+		_, err = db.Exec("INSERT INTO user_follows (follower_id, followed_id, created_at) VALUES (?, ?, ?)",
+			userID, followedID, time.Now())
+	}
+	if err != nil {
+		log.Printf("Error updating follow: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error updating follow")
+		return
+	}
+
+	// Let the user know they gained a follower.
+	if !following {
+		var follower string
+		if err := db.QueryRow("SELECT username FROM users WHERE id = ?", userID).Scan(&follower); err == nil {
+			message := fmt.Sprintf("%s started following you", follower)
+			link := "/u/" + url.PathEscape(follower)
+			if err := CreateNotification(db, followedID, "follow", message, link); err != nil {
+				log.Printf("Error creating follow notification: %v", err)
+			}
+		}
+	}
+
+	http.Redirect(w, r, redirectTarget(r, "/feed"), http.StatusSeeOther)
+}
+
+// FollowCategoryHandler follows or, if already followed, unfollows the category given by "category_id".
+func FollowCategoryHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests change state.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	userID, err := GetUserIDFromSession(r, db)
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusUnauthorized, "User is not authorised")
+		return
+	}
+
+	categoryID, err := strconv.Atoi(r.FormValue("category_id"))
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Invalid category ID")
+		return
+	}
+
+	var exists bool
+	err = db.QueryRow("SELECT EXISTS(SELECT 1 FROM categories WHERE id = ?)", categoryID).Scan(&exists)
+	if err != nil || !exists {
+		RenderErrorPage(w, r, db, http.StatusNotFound, "Category not found")
+		return
+	}
+
+	var following bool
+	err = db.QueryRow("SELECT EXISTS(SELECT 1 FROM category_follows WHERE user_id = ? AND category_id = ?)",
+		userID, categoryID).Scan(&following)
+	if err != nil {
+		log.Printf("Error checking category follow: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	if following {
+		_, err = db.Exec("DELETE FROM category_follows WHERE user_id = ? AND category_id = ?", userID, categoryID)
+	} else {
+		_, err = db.Exec("INSERT INTO category_follows (user_id, category_id, created_at) VALUES (?, ?, ?)",
+			userID, categoryID, time.Now())
+	}
+	if err != nil {
+		log.Printf("Error updating category follow: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error updating follow")
+		return
+	}
+
+	http.Redirect(w, r, redirectTarget(r, "/categories"), http.StatusSeeOther)
+}
+
+// FeedHandler renders "/feed": the newest posts from followed authors and categories.
+func FeedHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only GET requests are supported.
+	if r.Method != http.MethodGet {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	userID, err := GetUserIDFromSession(r, db)
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusUnauthorized, "User is not authorised")
+		return
+	}
+
+	user := &models.User{}
+	err = db.QueryRow("SELECT id, username FROM users WHERE id = ?", userID).Scan(&user.ID, &user.Username)
+	if err != nil {
+		log.Printf("Error getting the user: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading user")
+		return
+	}
+
+	posts, err := loadFeedPosts(db, userID, feedLimit)
+	if err != nil {
+		log.Printf("Error loading feed: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading feed")
+		return
+	}
+	if err := markUnread(db, userID, posts); err != nil {
+		log.Printf("Error loading unread counts: %v", err)
+	}
+
+	categories, err := loadCategories(db)
+	if err != nil {
+		log.Printf("Error loading categories: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading categories")
+		return
+	}
+
+	pageData := models.PostsPageData{
+		Posts:      posts,
+		User:       user,
+		Categories: categories,
+	}
+
+	tmpl, err := template.ParseFiles("assets/template/header.html", "assets/template/feed.html")
+	if err != nil {
+		log.Printf("Error loading template: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading template")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	if err := tmpl.ExecuteTemplate(w, "feed", pageData); err != nil {
+		log.Printf("Rendering error: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Rendering page error")
+	}
+}
+
+// loadFeedPosts returns the newest posts written by followed users or filed in followed categories.
+func loadFeedPosts(db *sql.DB, userID, limit int) ([]models.Post, error) {
+	rows, err := db.Query(`
+		SELECT p.id, p.user_id, u.username, p.title, p.body, p.category_id, c.name, p.created_at,
+		       p.likes, p.dislikes, p.comment_count, p.last_activity_at
+		FROM posts p
+		JOIN users u ON p.user_id = u.id
+		JOIN categories c ON p.category_id = c.id
+		WHERE (p.user_id IN (SELECT followed_id FROM user_follows WHERE follower_id = ?)
+		   OR p.category_id IN (SELECT category_id FROM category_follows WHERE user_id = ?))
+		  AND `+visibleAuthor("p.user_id")+` AND `+unhidden("p")+` AND `+publishedPost+`
+		ORDER BY p.created_at DESC
+		LIMIT ?`, userID, userID, userID, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []models.Post
+	for rows.Next() {
+		var post models.Post
+		if err := rows.Scan(&post.ID, &post.UserID, &post.Author, &post.Title, &post.Body,
+			&post.CategoryID, &post.CategoryName, &post.CreatedAt,
+			&post.Likes, &post.Dislikes, &post.CommentCount, &post.LastActivity); err != nil {
+			return nil, err
+		}
+		post.Body = truncate(post.Body, 200)
+		posts = append(posts, post)
+	}
+	return posts, rows.Err()
+}
+
+// isFollowingUser reports whether followerID follows followedID.
+func isFollowingUser(db *sql.DB, followerID, followedID int) (bool, error) {
+	var following bool
+	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM user_follows WHERE follower_id = ? AND followed_id = ?)",
+		followerID, followedID).Scan(&following)
+	return following, err
+}
+
+// countFollows returns how many users follow the user and how many users the user follows.
+func countFollows(db *sql.DB, userID int) (followers, following int, err error) {
+	err = db.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM user_follows WHERE followed_id = ?),
+		       (SELECT COUNT(*) FROM user_follows WHERE follower_id = ?)`,
+		userID, userID).Scan(&followers, &following)
+	return followers, following, err
+}
+
+// followedCategoryIDs returns the set of category IDs the user follows.
+func followedCategoryIDs(db *sql.DB, userID int) (map[int]bool, error) {
+	followed := make(map[int]bool)
+	rows, err := db.Query("SELECT category_id FROM category_follows WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var categoryID int
+		if err := rows.Scan(&categoryID); err != nil {
+			return nil, err
+		}
+		followed[categoryID] = true
+	}
+	return followed, rows.Err()
+}
+
+// redirectTarget returns the local path from the "redirect" form value, or the fallback.
+// Only paths on this site are accepted so the form cannot be used as an open redirect.
+func redirectTarget(r *http.Request, fallback string) string {
+	if target := r.FormValue("redirect"); isLocalPath(target) {
+		return target
+	}
+	return fallback
+}
+
+// isLocalPath reports whether target is a path on this site: a single leading "/" and no scheme
+// or host. Browsers read "//host" and "/\host" as links to another site, so a second "/" and
+// any backslash are refused.
+func isLocalPath(target string) bool {
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.Contains(target, `\`) {
+		return false
+	}
+	parsed, err := url.Parse(target)
+	return err == nil && parsed.Scheme == "" && parsed.Host == ""
+}
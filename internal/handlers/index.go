@@ -24,7 +24,11 @@ func HandleIndex(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 	}
 
 	// Query the database for the 10 most recent posts, ordered by creation date.
-	rows, err := db.Query("SELECT id, title FROM posts ORDER BY created_at DESC LIMIT 10")
+	// Posts by shadowbanned users are shown only to their authors, and posts hidden by reports
+	// only to their authors and moderators.
+	viewer := viewerID(r, db)
+	rows, err := db.Query("SELECT p.id, p.title FROM posts p WHERE "+visibleAuthor("p.user_id")+" AND "+unhidden("p")+
+		" AND "+publishedPost+" "+pinnedOrder(false)+" LIMIT 10", viewer, viewer)
 	if err != nil {
 		// Log the error and return a 500 Internal Server Error if the query fails.
 		log.Printf("Error getting posts from database: %v", err)
@@ -71,6 +75,18 @@ func HandleIndex(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 		}
 	}
 
+	// Logged-in users who follow authors or categories see their personalized feed instead of the latest posts.
+	isFeed := false
+	if user != nil {
+		feedPosts, err := loadFeedPosts(db, user.ID, 10)
+		if err != nil {
+			log.Printf("Error loading feed: %v", err)
+		} else if len(feedPosts) > 0 {
+			posts = feedPosts
+			isFeed = true
+		}
+	}
+
 	// Query the database for all available categories.
 	rowsCategory, err := db.Query("SELECT id, name FROM categories")
 	if err != nil {
@@ -108,6 +124,7 @@ func HandleIndex(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 		Posts:      posts,      // List of posts to display
 		User:       user,       // Currently logged-in user (if any)
 		Categories: categories, // List of categories to display
+		IsFeed:     isFeed,     // Whether the posts come from the user's feed
 	}
 
 	// Parse the necessary HTML template files.
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"database/sql" // Provides SQL database support
+	"log"          // Used for logging errors
+	"time"         // Used for job intervals and times of day
+)
+
+// scheduledJob is a background task that runs at a fixed interval or at a fixed time of night,
+// such as recomputing derived data.
+type scheduledJob struct {
+	Name     string              // Used in log messages
+	Interval time.Duration       // Time between runs, for jobs without DailyAt
+	DailyAt  time.Duration       // Local time of day a daily job runs at, e.g. 3*time.Hour; zero for interval jobs
+	Run      func(*sql.DB) error // The work itself
+}
+
+// scheduledJobs lists every background job. At startup each one runs once, in this order.
+var scheduledJobs = []scheduledJob{
+	{Name: "trust levels", Interval: time.Hour, Run: RecomputeTrustLevels},
+	{Name: "reputation", Interval: time.Hour, Run: RecomputeReputation},
+	{Name: "leaderboards", Interval: time.Hour, Run: RecomputeLeaderboards},
+	{Name: "nightly badges", DailyAt: 3 * time.Hour, Run: AwardAllBadges},
+	{Name: "scheduled posts", Interval: time.Minute, Run: PublishScheduledPosts},
+}
+
+// StartScheduledJobs runs every job once and then keeps each running on its schedule, in the
+// background. The first runs go one after another, so the jobs do not all write to the database
+// at once at startup.
+func StartScheduledJobs(db *sql.DB) {
+	go func() {
+		for _, job := range scheduledJobs {
+			job.runOnce(db)
+		}
+		for _, job := range scheduledJobs {
+			go func(job scheduledJob) {
+				for {
+					time.Sleep(job.untilNextRun(time.Now()))
+					job.runOnce(db)
+				}
+			}(job)
+		}
+	}()
+}
+
+// runOnce runs the job, logging any error.
+func (job scheduledJob) runOnce(db *sql.DB) {
+	if err := job.Run(db); err != nil {
+		log.Printf("Error running scheduled job %q: %v", job.Name, err)
+	}
+}
+
+// untilNextRun returns how long to wait from now until the job's next run: its interval, or the
+// time left until its next time of day.
+func (job scheduledJob) untilNextRun(now time.Time) time.Duration {
+	if job.DailyAt == 0 {
+		return job.Interval
+	}
+	hour, minute := int(job.DailyAt/time.Hour), int(job.DailyAt%time.Hour/time.Minute)
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	if !next.After(now) {
+		next = time.Date(now.Year(), now.Month(), now.Day()+1, hour, minute, 0, 0, now.Location())
+	}
+	return next.Sub(now)
+}
@@ -0,0 +1,325 @@
+package handlers
+
+import (
+	"database/sql"                          // Provides SQL database support
+	"html/template"                         // Used for rendering HTML templates
+	models "literary-lions/internal/models" // Imports the models package for structured data types
+	"log"                                   // Used for logging errors
+	"math"                                  // Used to round reputation scores
+	"net/http"                              // Provides HTTP client and server implementations
+	"sort"                                  // Used to rank contributors
+	"strconv"                               // Used to parse the category filter
+	"time"                                  // Used for period boundaries
+)
+
+// leaderboardSize is how many contributors each leaderboard lists.
+const leaderboardSize = 10
+
+// leaderboardPeriod is a time window a leaderboard covers.
+type leaderboardPeriod struct {
+	Key   string        // Stored in "leaderboard_entries.period" and used in "?period="
+	Label string        // Shown on the page
+	Span  time.Duration // Length of the window; 0 means all time
+}
+
+// leaderboardPeriods lists the periods, in the order they are offered.
+var leaderboardPeriods = []leaderboardPeriod{
+	{Key: "week", Label: "This week", Span: 7 * 24 * time.Hour},
+	{Key: "month", Label: "This month", Span: 30 * 24 * time.Hour},
+	{Key: "all", Label: "All time"},
+}
+
+// leaderboardMetric is one way of ranking contributors. Query returns category ID, user ID and
+// score rows for activity since its single argument; overall boards add up the categories.
+// Metrics whose overall board is not such a sum compute every board with Boards instead.
+type leaderboardMetric struct {
+	Key    string                                                         // Stored in "leaderboard_entries.metric"
+	Title  string                                                         // Heading of the leaderboard
+	Query  string                                                         // Scores per category and user
+	Boards func(db *sql.DB, since time.Time) (map[int]map[int]int, error) // Scores by category ID, 0 for overall, and user ID
+}
+
+// excludeShadowbanned hides shadowbanned users from the public leaderboards.
+const excludeShadowbanned = "NOT IN (SELECT user_id FROM user_sanctions WHERE kind = 'shadowban' AND lifted_at IS NULL)"
+
+// leaderboardMetrics lists the metrics, in the order they are shown.
+var leaderboardMetrics = []leaderboardMetric{
+	{
+		// Reputation from the reactions of the period, scored like the reputation shown next to names.
+		Key:    "reputation",
+		Title:  "Reputation earned",
+		Boards: reputationBoards,
+	},
+	{
+		Key:   "posts",
+		Title: "Most posts",
+		Query: `
+			SELECT category_id, user_id, COUNT(*) FROM posts
+			WHERE created_at >= ? AND publish_at IS NULL AND user_id ` + excludeShadowbanned + `
+			GROUP BY category_id, user_id`,
+	},
+	{
+		// Comments that other members liked more than they disliked.
+		Key:   "helpful",
+		Title: "Most helpful comments",
+		Query: `
+			SELECT p.category_id, c.user_id, COUNT(*)
+			FROM comments c JOIN posts p ON p.id = c.post_id
+			WHERE c.created_at >= ? AND c.user_id ` + excludeShadowbanned + `
+			  AND (SELECT COALESCE(SUM(CASE WHEN l.reaction = 'like' THEN 1 ELSE -1 END), 0) FROM likes_dislikes l
+			       WHERE l.target_type = 'comment' AND l.target_id = c.id AND l.user_id != c.user_id
+			         AND l.reaction IN ('like', 'dislike')) > 0
+			GROUP BY p.category_id, c.user_id`,
+	},
+}
+
+// RecomputeLeaderboards ranks contributors for every period, metric and category and replaces
+// the stored leaderboards, so the leaderboard page never has to aggregate reactions itself.
+func RecomputeLeaderboards(db *sql.DB) error {
+	type board struct {
+		period, metric string
+		categoryID     int // 0 for the overall board
+	}
+	scores := make(map[board]map[int]int) // Scores by user ID
+
+	now := time.Now()
+	for _, period := range leaderboardPeriods {
+		since := time.Time{}
+		if period.Span > 0 {
+			since = now.Add(-period.Span)
+		}
+		for _, metric := range leaderboardMetrics {
+			if metric.Boards != nil {
+				boards, err := metric.Boards(db, since)
+				if err != nil {
+					return err
+				}
+				for categoryID, byUser := range boards {
+					scores[board{period.Key, metric.Key, categoryID}] = byUser
+				}
+				continue
+			}
+			rows, err := db.Query(metric.Query, since)
+			if err != nil {
+				return err
+			}
+			for rows.Next() {
+				var categoryID, userID, score int
+				if err := rows.Scan(&categoryID, &userID, &score); err != nil {
+					rows.Close()
+					return err
+				}
+				for _, b := range []board{{period.Key, metric.Key, categoryID}, {period.Key, metric.Key, 0}} {
+					if scores[b] == nil {
+						scores[b] = make(map[int]int)
+					}
+					scores[b][userID] += score
+				}
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Replace the stored leaderboards in one go so readers never see a half-updated table.
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM leaderboard_entries"); err != nil {
+		return err
+	}
+	for b, byUser := range scores {
+		var userIDs []int
+		for userID, score := range byUser {
+			if score > 0 {
+				userIDs = append(userIDs, userID)
+			}
+		}
+		// Highest score first; ties go to the older account.
+		sort.Slice(userIDs, func(i, j int) bool {
+			if byUser[userIDs[i]] != byUser[userIDs[j]] {
+				return byUser[userIDs[i]] > byUser[userIDs[j]]
+			}
+			return userIDs[i] < userIDs[j]
+		})
+		if len(userIDs) > leaderboardSize {
+			userIDs = userIDs[:leaderboardSize]
+		}
+		for i, userID := range userIDs {
+			_, err := tx.Exec(`
+				INSERT INTO leaderboard_entries (period, metric, category_id, rank, user_id, score, computed_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?)`,
+				b.period, b.metric, b.categoryID, i+1, userID, byUser[userID], now)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// reputationBoards scores the reputation earned since the given time with the rules of
+// RecomputeReputation, so the all-time overall board matches the stored reputation. Vote rings are
+// found from every reaction ever given, and the voter cap applies per board, so the overall board
+// is not the sum of the category boards. Shadowbanned authors are left out.
+func reputationBoards(db *sql.DB, since time.Time) (map[int]map[int]int, error) {
+	reactions, likes, err := loadReactions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	shadowbanned := make(map[int]bool)
+	rows, err := db.Query("SELECT user_id FROM user_sanctions WHERE kind = 'shadowban' AND lifted_at IS NULL")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		shadowbanned[userID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	byCategory := make(map[int][]reaction) // Category 0 collects every reaction, for the overall board
+	for _, r := range reactions {
+		if r.createdAt.Before(since) || shadowbanned[r.author] {
+			continue
+		}
+		byCategory[0] = append(byCategory[0], r)
+		byCategory[r.categoryID] = append(byCategory[r.categoryID], r)
+	}
+
+	rings := voteRings(likes)
+	now := time.Now()
+	boards := make(map[int]map[int]int)
+	for categoryID, categoryReactions := range byCategory {
+		boards[categoryID] = make(map[int]int)
+		for userID, score := range reputationScores(categoryReactions, rings, now) {
+			boards[categoryID][userID] = int(math.Round(score))
+		}
+	}
+	return boards, nil
+}
+
+// LeaderboardHandler renders "/leaderboard", the top contributors for the period and category
+// chosen with "?period=" and "?category=".
+func LeaderboardHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only GET requests are supported.
+	if r.Method != http.MethodGet {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	period := leaderboardPeriods[0]
+	if key := r.URL.Query().Get("period"); key != "" {
+		found := false
+		for _, p := range leaderboardPeriods {
+			if p.Key == key {
+				period, found = p, true
+			}
+		}
+		if !found {
+			RenderErrorPage(w, r, db, http.StatusBadRequest, "Unknown period")
+			return
+		}
+	}
+
+	categoryID := 0
+	if value := r.URL.Query().Get("category"); value != "" {
+		var err error
+		categoryID, err = strconv.Atoi(value)
+		if err != nil || categoryID < 0 {
+			RenderErrorPage(w, r, db, http.StatusBadRequest, "Invalid category")
+			return
+		}
+	}
+
+	// The viewer may be anonymous.
+	var user *models.User
+	if viewerID, err := GetUserIDFromSession(r, db); err == nil {
+		user = &models.User{}
+		err = db.QueryRow("SELECT id, username FROM users WHERE id = ?", viewerID).Scan(&user.ID, &user.Username)
+		if err != nil {
+			log.Printf("Error getting the user: %v", err)
+			user = nil
+		}
+	}
+
+	pageData := models.LeaderboardPageData{
+		User:       user,
+		Period:     period.Key,
+		CategoryID: categoryID,
+	}
+	for _, p := range leaderboardPeriods {
+		pageData.Periods = append(pageData.Periods, models.LeaderboardPeriod{Key: p.Key, Label: p.Label})
+	}
+
+	for _, metric := range leaderboardMetrics {
+		entries, computedAt, err := loadLeaderboard(db, period.Key, metric.Key, categoryID)
+		if err != nil {
+			log.Printf("Error loading leaderboard: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading leaderboard")
+			return
+		}
+		if computedAt.After(pageData.ComputedAt) {
+			pageData.ComputedAt = computedAt
+		}
+		pageData.Boards = append(pageData.Boards, models.Leaderboard{Title: metric.Title, Entries: entries})
+	}
+
+	categories, err := loadCategories(db)
+	if err != nil {
+		log.Printf("Error loading categories: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading categories")
+		return
+	}
+	pageData.Categories = categories
+
+	tmpl, err := template.ParseFiles("assets/template/header.html", "assets/template/leaderboard.html")
+	if err != nil {
+		log.Printf("Error loading template: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading template")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	if err := tmpl.ExecuteTemplate(w, "leaderboard", pageData); err != nil {
+		log.Printf("Rendering error: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Rendering page error")
+	}
+}
+
+// loadLeaderboard returns the stored ranking of one board and when it was computed.
+func loadLeaderboard(db *sql.DB, period, metric string, categoryID int) ([]models.LeaderboardEntry, time.Time, error) {
+	rows, err := db.Query(`
+		SELECT e.rank, e.user_id, u.username, e.score, e.computed_at
+		FROM leaderboard_entries e
+		JOIN users u ON u.id = e.user_id
+		WHERE e.period = ? AND e.metric = ? AND e.category_id = ?
+		ORDER BY e.rank`, period, metric, categoryID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer rows.Close()
+
+	var entries []models.LeaderboardEntry
+	var computedAt time.Time
+	for rows.Next() {
+		var entry models.LeaderboardEntry
+		if err := rows.Scan(&entry.Rank, &entry.UserID, &entry.Username, &entry.Score, &computedAt); err != nil {
+			return nil, time.Time{}, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, computedAt, rows.Err()
+}
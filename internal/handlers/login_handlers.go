@@ -70,6 +70,18 @@ func HandleLogin(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 			return
 		}
 
+		// Suspended and banned users cannot log in.
+		restriction, err := activeRestriction(db, user.ID)
+		if err != nil {
+			log.Printf("Error checking sanctions: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Database error")
+			return
+		}
+		if restriction != nil {
+			renderLoginPage(w, r, db, restriction.Error())
+			return
+		}
+
 		// Create a new session token for the user.
 		sessionToken, err := utils.CreateSessionToken()
 		if err != nil {
@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"database/sql"                          // Provides SQL database support
+	"encoding/json"                         // Used to return autocomplete suggestions as JSON
+	"fmt"                                   // Used to format links and notification messages
+	"html/template"                         // Used for rendering HTML templates and escaping text
+	models "literary-lions/internal/models" // Imports the models package for structured data types
+	"log"                                   // Used for logging errors
+	"net/http"                              // Provides HTTP client and server implementations
+	"net/url"                               // Used to escape usernames in profile links
+	"regexp"                                // Used to find @username mentions in text
+	"strings"                               // Used for string manipulation
+	"time"                                  // Used to timestamp mentions
+)
+
+// mentionPattern matches "@username" where the username consists of letters, digits, '_', '.' or '-'.
+// The "@" must start the text or follow a character that cannot be part of a name, so addresses
+// like "bob@example.com" are not mentions. The match includes that character; group 1 is the name.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_])@([\p{L}\p{N}_.-]+)`)
+
+// parseMentions returns the distinct usernames mentioned in the text, in order of appearance.
+func parseMentions(text string) []string {
+	seen := make(map[string]bool)
+	var usernames []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		// Trailing dots usually end a sentence rather than belong to the name.
+		username := strings.TrimRight(match[1], ".")
+		if username == "" || seen[username] {
+			continue
+		}
+		seen[username] = true
+		usernames = append(usernames, username)
+	}
+	return usernames
+}
+
+// recordMentions stores the mentions found in a post or comment body and notifies newly mentioned users.
+// It replaces any mentions previously recorded for the same target, so it can be called again after an edit
+// without notifying the same user twice.
+func recordMentions(db *sql.DB, authorID int, targetType string, targetID, postID int, body string) {
+	usernames := parseMentions(body)
+
+	// Remember who was already mentioned so an edit does not re-notify them.
+	alreadyMentioned := make(map[int]bool)
+	rows, err := db.Query("SELECT user_id FROM mentions WHERE target_type = ? AND target_id = ?", targetType, targetID)
+	if err != nil {
+		log.Printf("Error loading existing mentions: %v", err)
+		return
+	}
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err == nil {
+			alreadyMentioned[userID] = true
+		}
+	}
+	rows.Close()
+
+	// Drop the old set of mentions; the current body is the source of truth.
+	_, err = db.Exec("DELETE FROM mentions WHERE target_type = ? AND target_id = ?", targetType, targetID)
+	if err != nil {
+		log.Printf("Error clearing mentions: %v", err)
+		return
+	}
+
+	var author string
+	if err := db.QueryRow("SELECT username FROM users WHERE id = ?", authorID).Scan(&author); err != nil {
+		log.Printf("Error loading mention author: %v", err)
+		return
+	}
+
+	for _, username := range usernames {
+		var mentionedID int
+		err := db.QueryRow("SELECT id FROM users WHERE username = ?", username).Scan(&mentionedID)
+		if err != nil {
+			// Mentions of unknown users are left as plain text.
+			continue
+		}
+		// Mentioning yourself is not recorded.
+		if mentionedID == authorID {
+			continue
+		}
+
+		_, err = db.Exec(`
+			INSERT OR IGNORE INTO mentions (user_id, author_id, target_type, target_id, post_id, created_at)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			mentionedID, authorID, targetType, targetID, postID, time.Now())
+		if err != nil {
+			log.Printf("Error saving mention of %s: %v", username, err)
+			continue
+		}
+
+		if alreadyMentioned[mentionedID] {
+			continue
+		}
+		message := fmt.Sprintf("%s mentioned you in a %s", author, targetType)
+		link := fmt.Sprintf("/post/%d", postID)
+		if targetType == "comment" {
+			link = fmt.Sprintf("/post/%d#comment-%d", postID, targetID)
+		}
+		if err := CreateNotification(db, mentionedID, "mention", message, link); err != nil {
+			log.Printf("Error creating mention notification: %v", err)
+		}
+	}
+}
+
+// mentionLinker returns a template function that escapes text and turns "@username" of existing users
+// into links to their public profiles. The usernames of a text are looked up in one query, and the
+// answers are kept for the other texts of the same page, so make a new linker for every render.
+func mentionLinker(db *sql.DB) func(string) template.HTML {
+	exists := make(map[string]bool) // Usernames looked up so far, and whether they belong to a user
+	return func(text string) template.HTML {
+		var unknown []interface{}
+		for _, username := range parseMentions(text) {
+			if _, seen := exists[username]; !seen {
+				exists[username] = false
+				unknown = append(unknown, username)
+			}
+		}
+		if len(unknown) > 0 {
+			placeholders := strings.TrimSuffix(strings.Repeat("?,", len(unknown)), ",")
+			rows, err := db.Query("SELECT username FROM users WHERE username IN ("+placeholders+")", unknown...)
+			if err != nil {
+				log.Printf("Error looking up mentioned users: %v", err)
+			} else {
+				for rows.Next() {
+					var username string
+					if err := rows.Scan(&username); err == nil {
+						exists[username] = true
+					}
+				}
+				rows.Close()
+			}
+		}
+
+		var out strings.Builder
+		last := 0
+		for _, loc := range mentionPattern.FindAllStringSubmatchIndex(text, -1) {
+			// Trailing dots usually end a sentence rather than belong to the name.
+			username := strings.TrimRight(text[loc[2]:loc[3]], ".")
+			if !exists[username] {
+				continue
+			}
+
+			// The match may start with the character before the "@", which stays plain text.
+			at := loc[2] - 1
+			out.WriteString(template.HTMLEscapeString(text[last:at]))
+			out.WriteString(fmt.Sprintf(`<a class="mention" href="/u/%s">@%s</a>`,
+				template.HTMLEscapeString(url.PathEscape(username)), template.HTMLEscapeString(username)))
+			last = loc[2] + len(username)
+		}
+		out.WriteString(template.HTMLEscapeString(text[last:]))
+		return template.HTML(out.String())
+	}
+}
+
+// UserSearchHandler returns usernames starting with the "q" parameter as JSON, for @mention autocomplete.
+func UserSearchHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only GET requests are supported.
+	if r.Method != http.MethodGet {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	prefix := strings.TrimSpace(r.URL.Query().Get("q"))
+	usernames := []string{}
+	if prefix != "" {
+		// Escape LIKE wildcards so they match literally.
+		escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix)
+		rows, err := db.Query(`
+			SELECT username FROM users
+			WHERE username LIKE ? ESCAPE '\'
+			ORDER BY username
+			LIMIT 10`, escaped+"%")
+		if err != nil {
+			log.Printf("Error searching users: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var username string
+			if err := rows.Scan(&username); err == nil {
+				usernames = append(usernames, username)
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(usernames)
+}
+
+// UserMentionsHandler lists the posts and comments that mention the logged-in user on "/user/mentions".
+func UserMentionsHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only GET requests are supported.
+	if r.Method != http.MethodGet {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	userID, err := GetUserIDFromSession(r, db)
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusUnauthorized, "User is not authorised")
+		return
+	}
+
+	user := &models.User{}
+	err = db.QueryRow("SELECT id, username FROM users WHERE id = ?", userID).Scan(&user.ID, &user.Username)
+	if err != nil {
+		log.Printf("Error getting the user: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading user")
+		return
+	}
+
+	// Join each mention with its post, and with the comment text when the mention is in a comment.
+	rows, err := db.Query(`
+		SELECT m.target_type, m.target_id, m.post_id, p.title, a.username,
+		       CASE WHEN m.target_type = 'comment' THEN COALESCE(c.body, '') ELSE p.body END,
+		       m.created_at
+		FROM mentions m
+		JOIN posts p ON p.id = m.post_id
+		JOIN users a ON a.id = m.author_id
+		LEFT JOIN comments c ON m.target_type = 'comment' AND c.id = m.target_id
+		WHERE m.user_id = ?
+		ORDER BY m.created_at DESC`, userID)
+	if err != nil {
+		log.Printf("Error loading mentions: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading mentions")
+		return
+	}
+	defer rows.Close()
+
+	var mentions []models.Mention
+	for rows.Next() {
+		var mention models.Mention
+		if err := rows.Scan(&mention.TargetType, &mention.TargetID, &mention.PostID, &mention.PostTitle,
+			&mention.Author, &mention.Body, &mention.CreatedAt); err != nil {
+			log.Printf("Error reading mentions: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading mentions")
+			return
+		}
+		mention.Body = truncate(mention.Body, 200)
+		mentions = append(mentions, mention)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error parsing mentions: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading mentions")
+		return
+	}
+
+	categories, err := loadCategories(db)
+	if err != nil {
+		log.Printf("Error loading categories: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading categories")
+		return
+	}
+
+	pageData := models.UserMentionsPageData{
+		User:       user,
+		Mentions:   mentions,
+		Categories: categories,
+	}
+
+	tmpl, err := template.ParseFiles("assets/template/header.html", "assets/template/user_mentions.html")
+	if err != nil {
+		log.Printf("Error loading template: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading template")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	if err := tmpl.ExecuteTemplate(w, "user_mentions", pageData); err != nil {
+		log.Printf("Rendering error: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Rendering page error")
+	}
+}
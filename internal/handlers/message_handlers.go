@@ -0,0 +1,610 @@
+package handlers
+
+import (
+	"database/sql"                          // Provides SQL database support
+	"fmt"                                   // Used to format redirects and placeholders
+	"html/template"                         // Used for rendering HTML templates
+	models "literary-lions/internal/models" // Imports the models package for structured data types
+	"log"                                   // Used for logging errors
+	"net/http"                              // Provides HTTP client and server implementations
+	"net/url"                               // Used to escape error messages in redirects
+	"strconv"                               // Used to parse conversation IDs
+	"strings"                               // Used for string manipulation
+	"time"                                  // Used to timestamp conversations and messages
+)
+
+// maxConversationMembers limits group conversations to a small circle of readers, the sender included.
+const maxConversationMembers = 8
+
+// InboxHandler renders the logged-in user's conversations on "/messages".
+func InboxHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only GET requests are supported.
+	if r.Method != http.MethodGet {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	// Messages are private, so a valid session is required.
+	userID, err := GetUserIDFromSession(r, db)
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusUnauthorized, "User is not authorised")
+		return
+	}
+
+	user := &models.User{}
+	err = db.QueryRow("SELECT id, username FROM users WHERE id = ?", userID).Scan(&user.ID, &user.Username)
+	if err != nil {
+		log.Printf("Error getting the user: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading user")
+		return
+	}
+
+	// For every conversation of the user, fetch the other members, the latest message
+	// and the number of messages from others that arrived after the user's last read message.
+	rows, err := db.Query(`
+		SELECT c.id, COALESCE(c.title, ''), cm.muted,
+		       (SELECT GROUP_CONCAT(u.username, ', ')
+		        FROM conversation_members om JOIN users u ON u.id = om.user_id
+		        WHERE om.conversation_id = c.id AND om.user_id != cm.user_id),
+		       COALESCE((SELECT m.body FROM messages m WHERE m.conversation_id = c.id ORDER BY m.id DESC LIMIT 1), ''),
+		       c.updated_at,
+		       (SELECT COUNT(*) FROM messages m
+		        WHERE m.conversation_id = c.id AND m.id > cm.last_read_message_id AND m.user_id != cm.user_id)
+		FROM conversations c
+		JOIN conversation_members cm ON cm.conversation_id = c.id
+		WHERE cm.user_id = ?
+		ORDER BY c.updated_at DESC`, userID)
+	if err != nil {
+		log.Printf("Error loading conversations: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading conversations")
+		return
+	}
+	defer rows.Close()
+
+	var conversations []models.Conversation
+	totalUnread := 0
+	for rows.Next() {
+		var conversation models.Conversation
+		var members sql.NullString
+		if err := rows.Scan(&conversation.ID, &conversation.Title, &conversation.Muted, &members,
+			&conversation.LastMessage, &conversation.UpdatedAt, &conversation.UnreadCount); err != nil {
+			log.Printf("Error reading conversations: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading conversations")
+			return
+		}
+		conversation.Members = members.String
+		conversation.LastMessage = truncate(conversation.LastMessage, 100)
+		// Muted conversations do not add to the total unread badge.
+		if !conversation.Muted {
+			totalUnread += conversation.UnreadCount
+		}
+		conversations = append(conversations, conversation)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error parsing conversations: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading conversations")
+		return
+	}
+
+	// Load the users blocked by the current user so they can be unblocked.
+	blocked, err := loadBlockedUsers(db, userID)
+	if err != nil {
+		log.Printf("Error loading blocked users: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading blocked users")
+		return
+	}
+
+	categories, err := loadCategories(db)
+	if err != nil {
+		log.Printf("Error loading categories: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading categories")
+		return
+	}
+
+	pageData := models.InboxPageData{
+		User:          user,
+		Conversations: conversations,
+		TotalUnread:   totalUnread,
+		BlockedUsers:  blocked,
+		Categories:    categories,
+		ErrorMessage:  r.URL.Query().Get("error"),
+	}
+
+	tmpl, err := template.ParseFiles("assets/template/header.html", "assets/template/inbox.html")
+	if err != nil {
+		log.Printf("Error loading template: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading template")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	if err := tmpl.ExecuteTemplate(w, "inbox", pageData); err != nil {
+		log.Printf("Rendering error: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Rendering page error")
+	}
+}
+
+// NewConversationHandler starts a one-to-one or group conversation from the form on "/messages".
+func NewConversationHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests are supported.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	userID, err := GetUserIDFromSession(r, db)
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusUnauthorized, "User is not authorised")
+		return
+	}
+
+	title := strings.TrimSpace(r.FormValue("title"))
+	body := strings.TrimSpace(r.FormValue("body"))
+	if body == "" {
+		redirectToInbox(w, r, "The message cannot be empty")
+		return
+	}
+
+	// Recipients are entered as comma-separated usernames.
+	var recipientIDs []int
+	seen := map[int]bool{userID: true}
+	for _, name := range strings.Split(r.FormValue("recipients"), ",") {
+		name = strings.TrimPrefix(strings.TrimSpace(name), "@")
+		if name == "" {
+			continue
+		}
+		var recipientID int
+		err := db.QueryRow("SELECT id FROM users WHERE username = ?", name).Scan(&recipientID)
+		if err == sql.ErrNoRows {
+			redirectToInbox(w, r, fmt.Sprintf("User %s is not found", name))
+			return
+		} else if err != nil {
+			log.Printf("Error looking up recipient: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Database error")
+			return
+		}
+		if seen[recipientID] {
+			continue
+		}
+		seen[recipientID] = true
+
+		// Neither side of a block can start a conversation with the other.
+		blocked, err := isBlockedEitherWay(db, userID, recipientID)
+		if err != nil {
+			log.Printf("Error checking blocks: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Database error")
+			return
+		}
+		if blocked {
+			redirectToInbox(w, r, fmt.Sprintf("You cannot message %s", name))
+			return
+		}
+		recipientIDs = append(recipientIDs, recipientID)
+	}
+
+	if len(recipientIDs) == 0 {
+		redirectToInbox(w, r, "Choose at least one recipient")
+		return
+	}
+	if len(recipientIDs)+1 > maxConversationMembers {
+		redirectToInbox(w, r, fmt.Sprintf("A conversation can have at most %d members", maxConversationMembers))
+		return
+	}
+
+	// A one-to-one conversation is reused instead of creating a duplicate thread.
+	conversationID := 0
+	if len(recipientIDs) == 1 && title == "" {
+		err = db.QueryRow(`
+			SELECT c.id FROM conversations c
+			WHERE COALESCE(c.title, '') = ''
+			  AND (SELECT COUNT(*) FROM conversation_members WHERE conversation_id = c.id) = 2
+			  AND EXISTS (SELECT 1 FROM conversation_members WHERE conversation_id = c.id AND user_id = ?)
+			  AND EXISTS (SELECT 1 FROM conversation_members WHERE conversation_id = c.id AND user_id = ?)
+			LIMIT 1`, userID, recipientIDs[0]).Scan(&conversationID)
+		if err != nil && err != sql.ErrNoRows {
+			log.Printf("Error looking up existing conversation: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Database error")
+			return
+		}
+	}
+
+	if conversationID == 0 {
+		conversationID, err = createConversation(db, userID, title, recipientIDs)
+		if err != nil {
+			log.Printf("Error creating conversation: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error creating conversation")
+			return
+		}
+	}
+
+	if err := sendMessage(db, conversationID, userID, body); err != nil {
+		log.Printf("Error sending message: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error sending message")
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/messages/%d", conversationID), http.StatusSeeOther)
+}
+
+// ConversationHandler serves "/messages/{id}" (GET to read, POST to reply) and "/messages/{id}/mute" (POST).
+func ConversationHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Extract the conversation ID from the URL path.
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 3 || pathParts[1] != "messages" {
+		RenderErrorPage(w, r, db, http.StatusNotFound, "Page not found")
+		return
+	}
+	conversationID, err := strconv.Atoi(pathParts[2])
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Incorrect ID of the conversation")
+		return
+	}
+
+	userID, err := GetUserIDFromSession(r, db)
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusUnauthorized, "User is not authorised")
+		return
+	}
+
+	// Only members may see or write to a conversation; others get a 404 so IDs are not leaked.
+	var muted bool
+	err = db.QueryRow("SELECT muted FROM conversation_members WHERE conversation_id = ? AND user_id = ?",
+		conversationID, userID).Scan(&muted)
+	if err == sql.ErrNoRows {
+		RenderErrorPage(w, r, db, http.StatusNotFound, "Conversation not found")
+		return
+	} else if err != nil {
+		log.Printf("Error checking conversation membership: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	// "/messages/{id}/mute" toggles notifications for this conversation.
+	if len(pathParts) > 3 && pathParts[3] == "mute" {
+		if r.Method != http.MethodPost {
+			RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+			return
+		}
+		_, err = db.Exec("UPDATE conversation_members SET muted = ? WHERE conversation_id = ? AND user_id = ?",
+			!muted, conversationID, userID)
+		if err != nil {
+			log.Printf("Error muting conversation: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error updating conversation")
+			return
+		}
+		http.Redirect(w, r, fmt.Sprintf("/messages/%d", conversationID), http.StatusSeeOther)
+		return
+	} else if len(pathParts) > 3 && pathParts[3] != "" {
+		RenderErrorPage(w, r, db, http.StatusNotFound, "Page not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		body := strings.TrimSpace(r.FormValue("body"))
+		if body == "" {
+			http.Redirect(w, r, fmt.Sprintf("/messages/%d?error=%s", conversationID,
+				url.QueryEscape("The message cannot be empty")), http.StatusSeeOther)
+			return
+		}
+
+		// Blocking is enforced on every message, not just when the conversation starts.
+		blocked, err := isBlockedInConversation(db, conversationID, userID)
+		if err != nil {
+			log.Printf("Error checking blocks: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Database error")
+			return
+		}
+		if blocked {
+			http.Redirect(w, r, fmt.Sprintf("/messages/%d?error=%s", conversationID,
+				url.QueryEscape("You cannot send messages to this conversation")), http.StatusSeeOther)
+			return
+		}
+
+		if err := sendMessage(db, conversationID, userID, body); err != nil {
+			log.Printf("Error sending message: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error sending message")
+			return
+		}
+		http.Redirect(w, r, fmt.Sprintf("/messages/%d", conversationID), http.StatusSeeOther)
+	case http.MethodGet:
+		renderConversation(w, r, db, conversationID, userID, muted)
+	default:
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+	}
+}
+
+// renderConversation shows the messages of a conversation and marks them as read.
+func renderConversation(w http.ResponseWriter, r *http.Request, db *sql.DB, conversationID, userID int, muted bool) {
+	user := &models.User{}
+	err := db.QueryRow("SELECT id, username FROM users WHERE id = ?", userID).Scan(&user.ID, &user.Username)
+	if err != nil {
+		log.Printf("Error getting the user: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading user")
+		return
+	}
+
+	// Load the title and the names of the other members.
+	conversation := models.Conversation{ID: conversationID, Muted: muted}
+	var members sql.NullString
+	err = db.QueryRow(`
+		SELECT COALESCE(c.title, ''),
+		       (SELECT GROUP_CONCAT(u.username, ', ')
+		        FROM conversation_members om JOIN users u ON u.id = om.user_id
+		        WHERE om.conversation_id = c.id AND om.user_id != ?)
+		FROM conversations c WHERE c.id = ?`, userID, conversationID).Scan(&conversation.Title, &members)
+	if err != nil {
+		log.Printf("Error loading conversation: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading conversation")
+		return
+	}
+	conversation.Members = members.String
+
+	rows, err := db.Query(`
+		SELECT m.id, m.conversation_id, m.user_id, u.username, m.body, m.created_at
+		FROM messages m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.conversation_id = ?
+		ORDER BY m.id`, conversationID)
+	if err != nil {
+		log.Printf("Error loading messages: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading messages")
+		return
+	}
+	defer rows.Close()
+
+	var messages []models.Message
+	lastID := 0
+	for rows.Next() {
+		var message models.Message
+		if err := rows.Scan(&message.ID, &message.ConversationID, &message.UserID, &message.Username,
+			&message.Body, &message.CreatedAt); err != nil {
+			log.Printf("Error reading messages: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading messages")
+			return
+		}
+		lastID = message.ID
+		messages = append(messages, message)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error parsing messages: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading messages")
+		return
+	}
+
+	// Everything shown is now read.
+	_, err = db.Exec("UPDATE conversation_members SET last_read_message_id = ? WHERE conversation_id = ? AND user_id = ?",
+		lastID, conversationID, userID)
+	if err != nil {
+		log.Printf("Error marking conversation as read: %v", err)
+	}
+
+	categories, err := loadCategories(db)
+	if err != nil {
+		log.Printf("Error loading categories: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading categories")
+		return
+	}
+
+	pageData := models.ConversationPageData{
+		User:         user,
+		Conversation: conversation,
+		Messages:     messages,
+		Categories:   categories,
+		ErrorMessage: r.URL.Query().Get("error"),
+	}
+
+	tmpl, err := template.ParseFiles("assets/template/header.html", "assets/template/conversation.html")
+	if err != nil {
+		log.Printf("Error loading template: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading template")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	if err := tmpl.ExecuteTemplate(w, "conversation", pageData); err != nil {
+		log.Printf("Rendering error: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Rendering page error")
+	}
+}
+
+// BlockUserHandler blocks the user named in the form, preventing private messages in both directions.
+func BlockUserHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests are supported.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	userID, err := GetUserIDFromSession(r, db)
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusUnauthorized, "User is not authorised")
+		return
+	}
+
+	username := strings.TrimPrefix(strings.TrimSpace(r.FormValue("username")), "@")
+	var blockedID int
+	err = db.QueryRow("SELECT id FROM users WHERE username = ?", username).Scan(&blockedID)
+	if err == sql.ErrNoRows {
+		redirectToInbox(w, r, fmt.Sprintf("User %s is not found", username))
+		return
+	} else if err != nil {
+		log.Printf("Error looking up user to block: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if blockedID == userID {
+		redirectToInbox(w, r, "You cannot block yourself")
+		return
+	}
+
+	_, err = db.Exec("INSERT OR IGNORE INTO user_blocks (blocker_id, blocked_id, created_at) VALUES (?, ?, ?)",
+		userID, blockedID, time.Now())
+	if err != nil {
+		log.Printf("Error blocking user: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error blocking user")
+		return
+	}
+
+	http.Redirect(w, r, "/messages", http.StatusSeeOther)
+}
+
+// UnblockUserHandler removes a block created by the logged-in user.
+func UnblockUserHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests are supported.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	userID, err := GetUserIDFromSession(r, db)
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusUnauthorized, "User is not authorised")
+		return
+	}
+
+	blockedID, err := strconv.Atoi(r.FormValue("user_id"))
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	_, err = db.Exec("DELETE FROM user_blocks WHERE blocker_id = ? AND blocked_id = ?", userID, blockedID)
+	if err != nil {
+		log.Printf("Error unblocking user: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error unblocking user")
+		return
+	}
+
+	http.Redirect(w, r, "/messages", http.StatusSeeOther)
+}
+
+// createConversation inserts a conversation with the creator and recipients as members.
+func createConversation(db *sql.DB, creatorID int, title string, recipientIDs []int) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.Exec("INSERT INTO conversations (title, created_by, created_at, updated_at) VALUES (?, ?, ?, ?)",
+		title, creatorID, now, now)
+	if err != nil {
+		return 0, err
+	}
+	conversationID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, memberID := range append([]int{creatorID}, recipientIDs...) {
+		_, err = tx.Exec("INSERT INTO conversation_members (conversation_id, user_id, joined_at) VALUES (?, ?, ?)",
+			conversationID, memberID, now)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return int(conversationID), tx.Commit()
+}
+
+// sendMessage stores a message, marks it read for the sender and pushes it to the other members' streams.
+func sendMessage(db *sql.DB, conversationID, senderID int, body string) error {
+	now := time.Now()
+	result, err := db.Exec("INSERT INTO messages (conversation_id, user_id, body, created_at) VALUES (?, ?, ?, ?)",
+		conversationID, senderID, body, now)
+	if err != nil {
+		return err
+	}
+	messageID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	// Bump the conversation to the top of everyone's inbox and mark the sender's own message as read.
+	if _, err := db.Exec("UPDATE conversations SET updated_at = ? WHERE id = ?", now, conversationID); err != nil {
+		return err
+	}
+	if _, err := db.Exec("UPDATE conversation_members SET last_read_message_id = ? WHERE conversation_id = ? AND user_id = ?",
+		messageID, conversationID, senderID); err != nil {
+		return err
+	}
+
+	var sender string
+	if err := db.QueryRow("SELECT username FROM users WHERE id = ?", senderID).Scan(&sender); err != nil {
+		return err
+	}
+
+	// Muted members still receive the message but are not pinged about it.
+	rows, err := db.Query("SELECT user_id FROM conversation_members WHERE conversation_id = ? AND user_id != ? AND muted = 0",
+		conversationID, senderID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var memberID int
+		if err := rows.Scan(&memberID); err != nil {
+			return err
+		}
+		Events.Publish(UserTopic(memberID), "message", map[string]interface{}{
+			"conversation_id": conversationID,
+			"username":        sender,
+			"body":            truncate(body, 100),
+			"link":            fmt.Sprintf("/messages/%d", conversationID),
+		})
+	}
+	return rows.Err()
+}
+
+// isBlockedEitherWay reports whether either user has blocked the other.
+func isBlockedEitherWay(db *sql.DB, userA, userB int) (bool, error) {
+	var blocked bool
+	err := db.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM user_blocks
+		WHERE (blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?))`,
+		userA, userB, userB, userA).Scan(&blocked)
+	return blocked, err
+}
+
+// isBlockedInConversation reports whether the sender has blocked, or is blocked by, any other member.
+func isBlockedInConversation(db *sql.DB, conversationID, senderID int) (bool, error) {
+	var blocked bool
+	err := db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM conversation_members cm
+			JOIN user_blocks b ON (b.blocker_id = cm.user_id AND b.blocked_id = ?)
+			                   OR (b.blocker_id = ? AND b.blocked_id = cm.user_id)
+			WHERE cm.conversation_id = ? AND cm.user_id != ?)`,
+		senderID, senderID, conversationID, senderID).Scan(&blocked)
+	return blocked, err
+}
+
+// loadBlockedUsers returns the users blocked by the given user.
+func loadBlockedUsers(db *sql.DB, userID int) ([]models.User, error) {
+	rows, err := db.Query(`
+		SELECT u.id, u.username FROM user_blocks b
+		JOIN users u ON u.id = b.blocked_id
+		WHERE b.blocker_id = ?
+		ORDER BY u.username`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.Username); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// redirectToInbox sends the user back to the inbox with an error message.
+func redirectToInbox(w http.ResponseWriter, r *http.Request, message string) {
+	http.Redirect(w, r, "/messages?error="+url.QueryEscape(message), http.StatusSeeOther)
+}
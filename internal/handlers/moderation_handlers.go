@@ -0,0 +1,669 @@
+package handlers
+
+import (
+	"database/sql"                          // Provides SQL database support
+	"fmt"                                   // Used to format links, notes and notification messages
+	"html/template"                         // Used for rendering HTML templates
+	models "literary-lions/internal/models" // Imports the models package for structured data types
+	"log"                                   // Used for logging errors
+	"net/http"                              // Provides HTTP client and server implementations
+	"net/url"                               // Used to escape error messages in redirects
+	"strconv"                               // Used to parse IDs and durations from forms
+	"strings"                               // Used for string manipulation
+	"time"                                  // Used to timestamp reports, actions and suspensions
+)
+
+// reportReasons lists the reason categories a member can pick when reporting content.
+var reportReasons = []string{"spam", "abuse", "spoiler", "off-topic", "other"}
+
+// defaultSuspensionDays is used when a moderator suspends a user without choosing a duration.
+const defaultSuspensionDays = 7
+
+// ReportHandler files a report about a post, comment or user.
+func ReportHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests change state.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	userID, err := GetUserIDFromSession(r, db)
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusUnauthorized, "User is not authorised")
+		return
+	}
+
+	report := models.Report{
+		ReporterID: userID,
+		TargetType: r.FormValue("target_type"),
+		Reason:     r.FormValue("reason"),
+		Details:    strings.TrimSpace(r.FormValue("details")),
+	}
+	report.TargetID, err = strconv.Atoi(r.FormValue("target_id"))
+	if err != nil || !isReportReason(report.Reason) {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Incorrect data")
+		return
+	}
+
+	// Make sure the target exists; this also rejects unknown target types.
+	if err := resolveReportTarget(db, &report); err != nil {
+		if err == sql.ErrNoRows {
+			RenderErrorPage(w, r, db, http.StatusNotFound, "Content not found")
+		} else {
+			log.Printf("Error resolving report target: %v", err)
+			RenderErrorPage(w, r, db, http.StatusBadRequest, "Incorrect data")
+		}
+		return
+	}
+	if report.OffenderID == userID {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "You cannot report yourself")
+		return
+	}
+
+	// One open report per member and target is enough for the queue.
+	var duplicate bool
+	err = db.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM reports
+		WHERE reporter_id = ? AND target_type = ? AND target_id = ? AND status = 'open')`,
+		userID, report.TargetType, report.TargetID).Scan(&duplicate)
+	if err != nil {
+		log.Printf("Error checking reports: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error saving report")
+		return
+	}
+
+	if !duplicate {
+		_, err = db.Exec(`
+			INSERT INTO reports (reporter_id, target_type, target_id, reason, details, weight, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			userID, report.TargetType, report.TargetID, report.Reason, report.Details, flagWeight(db, userID), time.Now())
+		if err != nil {
+			log.Printf("Error saving report: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error saving report")
+			return
+		}
+		// The report is filed either way, so a failure to hide the content is only logged.
+		if err := hideReportedContent(db, report.TargetType, report.TargetID); err != nil {
+			log.Printf("Error hiding reported content: %v", err)
+		}
+	}
+
+	http.Redirect(w, r, redirectTarget(r, report.TargetLink), http.StatusSeeOther)
+}
+
+// ModerationQueueHandler renders "/moderation", the queue of reports for moderators.
+// "?status=closed" shows the reports that were already handled.
+func ModerationQueueHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only GET requests are supported.
+	if r.Method != http.MethodGet {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	user, ok := requireModerator(w, r, db)
+	if !ok {
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	// Reports from more trusted members weigh more; the weight is fixed when the report is filed.
+	// The reported content and its author are joined in; deleted content comes back as NULLs.
+	query := `
+		SELECT r.id, r.reporter_id, u.username, r.target_type, r.target_id, r.reason,
+		       COALESCE(r.details, ''), r.status, r.created_at, r.weight,
+		       COALESCE(p.title, c.body, o.username), COALESCE(o.id, 0), COALESCE(o.username, ''), COALESCE(c.post_id, 0)
+		FROM reports r
+		JOIN users u ON u.id = r.reporter_id
+		LEFT JOIN posts p ON r.target_type = 'post' AND p.id = r.target_id
+		LEFT JOIN comments c ON r.target_type = 'comment' AND c.id = r.target_id
+		LEFT JOIN users o ON o.id = CASE r.target_type
+			WHEN 'post' THEN p.user_id WHEN 'comment' THEN c.user_id WHEN 'user' THEN r.target_id END`
+	if status == "closed" {
+		query += " WHERE r.status != 'open' ORDER BY r.resolved_at DESC LIMIT 100"
+	} else {
+		// The weightiest open reports are triaged first, then the oldest.
+		status = "open"
+		query += " WHERE r.status = 'open' ORDER BY r.weight DESC, r.created_at ASC"
+	}
+
+	rows, err := db.Query(query)
+	if err != nil {
+		log.Printf("Error loading reports: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading reports")
+		return
+	}
+	defer rows.Close()
+
+	var reports []models.Report
+	for rows.Next() {
+		var report models.Report
+		var summary sql.NullString
+		var commentPostID int
+		if err := rows.Scan(&report.ID, &report.ReporterID, &report.Reporter, &report.TargetType, &report.TargetID,
+			&report.Reason, &report.Details, &report.Status, &report.CreatedAt, &report.Weight,
+			&summary, &report.OffenderID, &report.Offender, &commentPostID); err != nil {
+			log.Printf("Error reading reports: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading reports")
+			return
+		}
+		// Describe what each report points at; deleted content is shown as such.
+		report.TargetSummary = "(deleted)"
+		if summary.Valid {
+			report.TargetSummary = truncate(summary.String, 150)
+			setReportTargetLink(&report, commentPostID)
+		}
+		reports = append(reports, report)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error parsing reports: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading reports")
+		return
+	}
+	rows.Close()
+
+	categories, err := loadCategories(db)
+	if err != nil {
+		log.Printf("Error loading categories: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading categories")
+		return
+	}
+
+	sanctions, err := loadActiveSanctions(db)
+	if err != nil {
+		log.Printf("Error loading sanctions: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading sanctions")
+		return
+	}
+
+	held, err := loadHeldSubmissions(db)
+	if err != nil {
+		log.Printf("Error loading held submissions: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading held submissions")
+		return
+	}
+
+	announcements, err := loadAnnouncements(db)
+	if err != nil {
+		log.Printf("Error loading announcements: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading announcements")
+		return
+	}
+
+	// Admins can preview every scheduled post, so they get the list of them.
+	var scheduled []models.Post
+	if user.Role == "admin" {
+		scheduled, err = loadScheduledPosts(db, 0)
+		if err != nil {
+			log.Printf("Error loading scheduled posts: %v", err)
+		}
+	}
+
+	pageData := models.ModerationPageData{
+		User:          user,
+		Reports:       reports,
+		Sanctions:     sanctions,
+		Held:          held,
+		Announcements: announcements,
+		Scheduled:     scheduled,
+		Status:        status,
+		Categories:    categories,
+		ErrorMessage:  r.URL.Query().Get("error"),
+	}
+
+	tmpl, err := template.ParseFiles("assets/template/header.html", "assets/template/moderation.html")
+	if err != nil {
+		log.Printf("Error loading template: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading template")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	if err := tmpl.ExecuteTemplate(w, "moderation", pageData); err != nil {
+		log.Printf("Rendering error: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Rendering page error")
+	}
+}
+
+// ModerationActionHandler triages a report: "dismiss" it, "delete" the reported content,
+// "warn" the responsible user, or "suspend", "ban" or "shadowban" them.
+// Every open report about the same target is closed, and the action is written to the moderation log.
+func ModerationActionHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests change state.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	moderator, ok := requireModerator(w, r, db)
+	if !ok {
+		return
+	}
+
+	reportID, err := strconv.Atoi(r.FormValue("report_id"))
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	var report models.Report
+	report.ID = reportID
+	err = db.QueryRow("SELECT target_type, target_id, reason, status FROM reports WHERE id = ?", reportID).
+		Scan(&report.TargetType, &report.TargetID, &report.Reason, &report.Status)
+	if err == sql.ErrNoRows {
+		RenderErrorPage(w, r, db, http.StatusNotFound, "Report not found")
+		return
+	} else if err != nil {
+		log.Printf("Error loading report: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading report")
+		return
+	}
+	if report.Status != "open" {
+		moderationError(w, r, "The report was already handled")
+		return
+	}
+
+	action := r.FormValue("action")
+	note := strings.TrimSpace(r.FormValue("note"))
+
+	// Everything except dismissing needs the reported content and its author.
+	targetErr := resolveReportTarget(db, &report)
+	if action != "dismiss" {
+		if targetErr != nil {
+			moderationError(w, r, "The reported content no longer exists; dismiss the report instead")
+			return
+		}
+		if report.OffenderID == moderator.ID {
+			moderationError(w, r, "You cannot moderate your own content")
+			return
+		}
+	}
+
+	status := "actioned"
+	details := note
+	days := 0
+	switch action {
+	case "dismiss":
+		status = "dismissed"
+	case "delete":
+		if report.TargetType == "user" {
+			moderationError(w, r, "Users cannot be deleted; warn or suspend them instead")
+			return
+		}
+	case "warn":
+		if note == "" {
+			moderationError(w, r, "A warning needs a note for the user")
+			return
+		}
+	case "suspend", "ban", "shadowban":
+		var role string
+		if err := db.QueryRow("SELECT role FROM users WHERE id = ?", report.OffenderID).Scan(&role); err != nil || isModeratorRole(role) {
+			moderationError(w, r, "Moderators cannot be sanctioned")
+			return
+		}
+		if action == "suspend" {
+			if days, err = sanctionDays(r); err != nil {
+				moderationError(w, r, "Invalid suspension: "+err.Error())
+				return
+			}
+		}
+		details = strings.TrimSpace(sanctionDetails(sanctionActions[action], days, note))
+	default:
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Unknown action")
+		return
+	}
+
+	// The action, the log entry and the report updates succeed or fail together.
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error applying action")
+		return
+	}
+	defer tx.Rollback()
+
+	// Deleting spam-reported content teaches the spam classifier what spam looks like,
+	// dismissing such a report teaches it what does not.
+	if report.Reason == "spam" && report.TargetType != "user" && targetErr == nil && (action == "delete" || action == "dismiss") {
+		label := labelHam
+		if action == "delete" {
+			label = labelSpam
+		}
+		var text string
+		text, err = contentText(tx, report.TargetType, report.TargetID)
+		if err == nil {
+			err = trainSpamExample(tx, label, text, moderator.ID)
+		}
+	}
+
+	// Warnings and sanctions are aimed at the author, so they are logged against the user.
+	logType, logID := report.TargetType, report.TargetID
+	var until time.Time
+	if err == nil {
+		switch action {
+		case "delete":
+			if report.TargetType == "post" {
+				err = deletePost(tx, report.TargetID)
+			} else {
+				err = deleteComment(tx, report.TargetID)
+			}
+		case "warn":
+			logType, logID = "user", report.OffenderID
+		case "suspend", "ban", "shadowban":
+			logType, logID = "user", report.OffenderID
+			until, err = imposeSanction(tx, report.OffenderID, moderator.ID, sanctionActions[action], days, note)
+		}
+	}
+	// Content the reports hid is shown again once a moderator decided to keep it.
+	if err == nil && action != "delete" && report.TargetType != "user" {
+		_, err = tx.Exec(fmt.Sprintf("UPDATE %ss SET hidden = 0 WHERE id = ?", report.TargetType), report.TargetID)
+	}
+	if err == nil {
+		err = logModeration(tx, moderator.ID, action, logType, logID, reportID, details)
+	}
+	if err == nil {
+		_, err = tx.Exec(`
+			UPDATE reports SET status = ?, resolved_by = ?, resolved_at = ?
+			WHERE target_type = ? AND target_id = ? AND status = 'open'`,
+			status, moderator.ID, time.Now(), report.TargetType, report.TargetID)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("Error applying moderation action: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error applying action")
+		return
+	}
+
+	// Tell the user what happened to them.
+	var message string
+	switch action {
+	case "delete":
+		message = fmt.Sprintf("A moderator removed your %s", report.TargetType)
+		if note != "" {
+			message += ". Note: " + note
+		}
+	case "warn":
+		message = "A moderator warned you: " + note
+	case "suspend", "ban", "shadowban":
+		notifySanction(db, report.OffenderID, sanctionActions[action], until, note)
+	}
+	if message != "" {
+		if err := CreateNotification(db, report.OffenderID, "moderation", message, "/user"); err != nil {
+			log.Printf("Error creating moderation notification: %v", err)
+		}
+	}
+
+	http.Redirect(w, r, "/moderation", http.StatusSeeOther)
+}
+
+// ModerationLogHandler renders "/moderation/log", the read-only history of moderator actions.
+func ModerationLogHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only GET requests are supported.
+	if r.Method != http.MethodGet {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	user, ok := requireModerator(w, r, db)
+	if !ok {
+		return
+	}
+
+	rows, err := db.Query(`
+		SELECT l.id, l.moderator_id, u.username, l.action, l.target_type, l.target_id,
+		       COALESCE(l.report_id, 0), COALESCE(l.details, ''), l.created_at
+		FROM moderation_log l
+		JOIN users u ON u.id = l.moderator_id
+		ORDER BY l.id DESC
+		LIMIT 200`)
+	if err != nil {
+		log.Printf("Error loading moderation log: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading moderation log")
+		return
+	}
+	defer rows.Close()
+
+	var entries []models.ModerationLogEntry
+	for rows.Next() {
+		var entry models.ModerationLogEntry
+		if err := rows.Scan(&entry.ID, &entry.ModeratorID, &entry.Moderator, &entry.Action, &entry.TargetType,
+			&entry.TargetID, &entry.ReportID, &entry.Details, &entry.CreatedAt); err != nil {
+			log.Printf("Error reading moderation log: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading moderation log")
+			return
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error parsing moderation log: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading moderation log")
+		return
+	}
+
+	categories, err := loadCategories(db)
+	if err != nil {
+		log.Printf("Error loading categories: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading categories")
+		return
+	}
+
+	pageData := models.ModerationLogPageData{
+		User:       user,
+		Entries:    entries,
+		Categories: categories,
+	}
+
+	tmpl, err := template.ParseFiles("assets/template/header.html", "assets/template/moderation_log.html")
+	if err != nil {
+		log.Printf("Error loading template: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading template")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	if err := tmpl.ExecuteTemplate(w, "moderation_log", pageData); err != nil {
+		log.Printf("Rendering error: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Rendering page error")
+	}
+}
+
+// requireModerator returns the logged-in user if they are a moderator or admin.
+// Otherwise it renders an error page and returns false.
+func requireModerator(w http.ResponseWriter, r *http.Request, db *sql.DB) (*models.User, bool) {
+	userID, err := GetUserIDFromSession(r, db)
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusUnauthorized, "User is not authorised")
+		return nil, false
+	}
+
+	user := &models.User{}
+	err = db.QueryRow("SELECT id, username, role FROM users WHERE id = ?", userID).Scan(&user.ID, &user.Username, &user.Role)
+	if err != nil {
+		log.Printf("Error getting the user: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading user")
+		return nil, false
+	}
+	if !isModeratorRole(user.Role) {
+		RenderErrorPage(w, r, db, http.StatusForbidden, "Only moderators can access this page")
+		return nil, false
+	}
+	return user, true
+}
+
+// isModeratorRole reports whether the role may use the moderation tools.
+func isModeratorRole(role string) bool {
+	return role == "moderator" || role == "admin"
+}
+
+// isModerator reports whether the user is a moderator or an admin.
+func isModerator(db *sql.DB, userID int) bool {
+	var role string
+	if err := db.QueryRow("SELECT role FROM users WHERE id = ?", userID).Scan(&role); err != nil {
+		return false
+	}
+	return isModeratorRole(role)
+}
+
+// isAdmin reports whether the user is an admin.
+func isAdmin(db *sql.DB, userID int) bool {
+	var role string
+	if err := db.QueryRow("SELECT role FROM users WHERE id = ?", userID).Scan(&role); err != nil {
+		return false
+	}
+	return role == "admin"
+}
+
+// isReportReason reports whether reason is one of the known report reason categories.
+func isReportReason(reason string) bool {
+	for _, known := range reportReasons {
+		if reason == known {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveReportTarget fills the summary, link and responsible user of the report's target.
+// It returns sql.ErrNoRows if the target no longer exists.
+func resolveReportTarget(db *sql.DB, report *models.Report) error {
+	var err error
+	var postID int
+	switch report.TargetType {
+	case "post":
+		err = db.QueryRow(`
+			SELECT p.title, p.user_id, u.username FROM posts p JOIN users u ON u.id = p.user_id
+			WHERE p.id = ?`, report.TargetID).Scan(&report.TargetSummary, &report.OffenderID, &report.Offender)
+	case "comment":
+		err = db.QueryRow(`
+			SELECT c.post_id, c.body, c.user_id, u.username FROM comments c JOIN users u ON u.id = c.user_id
+			WHERE c.id = ?`, report.TargetID).Scan(&postID, &report.TargetSummary, &report.OffenderID, &report.Offender)
+		report.TargetSummary = truncate(report.TargetSummary, 150)
+	case "user":
+		err = db.QueryRow("SELECT id, username FROM users WHERE id = ?", report.TargetID).
+			Scan(&report.OffenderID, &report.Offender)
+		report.TargetSummary = report.Offender
+	default:
+		return fmt.Errorf("unknown report target type %q", report.TargetType)
+	}
+	setReportTargetLink(report, postID)
+	return err
+}
+
+// setReportTargetLink fills the link to the report's target once its author is known.
+// postID is the post a reported comment belongs to.
+func setReportTargetLink(report *models.Report, postID int) {
+	switch report.TargetType {
+	case "post":
+		report.TargetLink = fmt.Sprintf("/post/%d", report.TargetID)
+	case "comment":
+		report.TargetLink = fmt.Sprintf("/post/%d#comment-%d", postID, report.TargetID)
+	case "user":
+		report.TargetLink = "/u/" + url.PathEscape(report.Offender)
+	}
+}
+
+// hideReportedContent hides a reported post or comment from other members once the open reports
+// about it weigh reportHideWeight together. Reports about users hide nothing.
+func hideReportedContent(db *sql.DB, targetType string, targetID int) error {
+	if targetType != "post" && targetType != "comment" {
+		return nil
+	}
+	_, err := db.Exec(fmt.Sprintf(`
+		UPDATE %ss SET hidden = 1
+		WHERE id = ? AND hidden = 0 AND (SELECT SUM(weight) FROM reports
+		                                 WHERE target_type = ? AND target_id = ? AND status = 'open') >= ?`, targetType),
+		targetID, targetType, targetID, reportHideWeight)
+	return err
+}
+
+// unhidden returns an SQL condition that keeps posts or comments hidden by reports from everyone but
+// their authors and moderators. table is the table name or alias the condition refers to. The condition
+// takes one argument: the viewer's user ID, or 0 for anonymous visitors.
+func unhidden(table string) string {
+	return fmt.Sprintf(`(%[1]s.hidden = 0 OR EXISTS (
+		SELECT 1 FROM users WHERE id = ? AND (id = %[1]s.user_id OR role IN ('moderator', 'admin'))))`, table)
+}
+
+// logModeration appends an entry to the moderation log. Entries cannot be changed afterwards.
+func logModeration(tx *sql.Tx, moderatorID int, action, targetType string, targetID, reportID int, details string) error {
+	var report interface{}
+	if reportID != 0 {
+		report = reportID
+	}
+	_, err := tx.Exec(`
+		INSERT INTO moderation_log (moderator_id, action, target_type, target_id, report_id, details, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		moderatorID, action, targetType, targetID, report, details, time.Now())
+	return err
+}
+
+// deletePost removes a post together with its comments and everything attached to them.
+func deletePost(tx *sql.Tx, postID int) error {
+	rows, err := tx.Query("SELECT id FROM comments WHERE post_id = ?", postID)
+	if err != nil {
+		return err
+	}
+	var commentIDs []int
+	for rows.Next() {
+		var commentID int
+		if err := rows.Scan(&commentID); err != nil {
+			rows.Close()
+			return err
+		}
+		commentIDs = append(commentIDs, commentID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, commentID := range commentIDs {
+		if err := deleteComment(tx, commentID); err != nil {
+			return err
+		}
+	}
+
+	statements := []string{
+		"DELETE FROM likes_dislikes WHERE target_type = 'post' AND target_id = ?",
+		"DELETE FROM mentions WHERE target_type = 'post' AND target_id = ?",
+		"DELETE FROM bookmarks WHERE target_type = 'post' AND target_id = ?",
+		"DELETE FROM post_reads WHERE post_id = ?",
+		"DELETE FROM poll_votes WHERE poll_id IN (SELECT id FROM polls WHERE post_id = ?)",
+		"DELETE FROM poll_ballots WHERE poll_id IN (SELECT id FROM polls WHERE post_id = ?)",
+		"DELETE FROM poll_options WHERE poll_id IN (SELECT id FROM polls WHERE post_id = ?)",
+		"DELETE FROM polls WHERE post_id = ?",
+		// Old URLs of threads merged into the post, or of comments split into it, have nowhere to lead anymore.
+		"DELETE FROM post_redirects WHERE to_post_id = ?",
+		"DELETE FROM posts WHERE id = ?",
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, postID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteComment removes a comment together with its likes, mentions and bookmarks,
+// and withdraws it as an accepted answer.
+func deleteComment(tx *sql.Tx, commentID int) error {
+	statements := []string{
+		"UPDATE posts SET accepted_comment_id = NULL WHERE accepted_comment_id = ?",
+		"DELETE FROM likes_dislikes WHERE target_type = 'comment' AND target_id = ?",
+		"DELETE FROM mentions WHERE target_type = 'comment' AND target_id = ?",
+		"DELETE FROM bookmarks WHERE target_type = 'comment' AND target_id = ?",
+		"DELETE FROM comments WHERE id = ?",
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, commentID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moderationError sends the moderator back to the queue with an error message.
+func moderationError(w http.ResponseWriter, r *http.Request, message string) {
+	http.Redirect(w, r, "/moderation?error="+url.QueryEscape(message), http.StatusSeeOther)
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"database/sql"                          // Provides SQL database support
+	"html/template"                         // Used for rendering HTML templates
+	models "literary-lions/internal/models" // Imports the models package for structured data types
+	"log"                                   // Used for logging errors
+	"net/http"                              // Provides HTTP client and server implementations
+	"time"                                  // Used to timestamp notifications
+)
+
+// CreateNotification stores a notification for the user and pushes it to their open event streams.
+func CreateNotification(db *sql.DB, userID int, kind, message, link string) error {
+	createdAt := time.Now()
+	result, err := db.Exec(
+		"INSERT INTO notifications (user_id, kind, message, link, created_at) VALUES (?, ?, ?, ?, ?)",
+		userID, kind, message, link, createdAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	notificationID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	// Deliver the notification in real time to any page the user has open.
+	Events.Publish(UserTopic(userID), "notification", map[string]interface{}{
+		"id":         notificationID,
+		"kind":       kind,
+		"message":    message,
+		"link":       link,
+		"created_at": createdAt.Format("02.01.2006 15:04"),
+	})
+	return nil
+}
+
+// NotificationsHandler renders the logged-in user's notifications on "/notifications".
+func NotificationsHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only GET requests are supported.
+	if r.Method != http.MethodGet {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	// Notifications are private, so a valid session is required.
+	userID, err := GetUserIDFromSession(r, db)
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusUnauthorized, "User is not authorised")
+		return
+	}
+
+	user := &models.User{}
+	err = db.QueryRow("SELECT id, username FROM users WHERE id = ?", userID).Scan(&user.ID, &user.Username)
+	if err != nil {
+		log.Printf("Error getting the user: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading user")
+		return
+	}
+
+	// Load the newest notifications first.
+	rows, err := db.Query(`
+		SELECT id, user_id, kind, message, COALESCE(link, ''), is_read, created_at
+		FROM notifications
+		WHERE user_id = ?
+		ORDER BY created_at DESC
+		LIMIT 100`, userID)
+	if err != nil {
+		log.Printf("Error loading notifications: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading notifications")
+		return
+	}
+	defer rows.Close()
+
+	var notifications []models.Notification
+	for rows.Next() {
+		var notification models.Notification
+		if err := rows.Scan(&notification.ID, &notification.UserID, &notification.Kind, &notification.Message,
+			&notification.Link, &notification.IsRead, &notification.CreatedAt); err != nil {
+			log.Printf("Error reading notifications: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading notifications")
+			return
+		}
+		notifications = append(notifications, notification)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error parsing notifications: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading notifications")
+		return
+	}
+
+	// Fetch categories for the header.
+	categories, err := loadCategories(db)
+	if err != nil {
+		log.Printf("Error loading categories: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading categories")
+		return
+	}
+
+	pageData := models.NotificationsPageData{
+		User:          user,
+		Notifications: notifications,
+		Categories:    categories,
+	}
+
+	tmpl, err := template.ParseFiles("assets/template/header.html", "assets/template/notifications.html")
+	if err != nil {
+		log.Printf("Error loading template: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading template")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	if err := tmpl.ExecuteTemplate(w, "notifications", pageData); err != nil {
+		log.Printf("Rendering error: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Rendering page error")
+	}
+}
+
+// MarkNotificationsReadHandler marks every notification of the logged-in user as read.
+func MarkNotificationsReadHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests change state.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	userID, err := GetUserIDFromSession(r, db)
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusUnauthorized, "User is not authorised")
+		return
+	}
+
+	_, err = db.Exec("UPDATE notifications SET is_read = 1 WHERE user_id = ?", userID)
+	if err != nil {
+		log.Printf("Error marking notifications as read: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error updating notifications")
+		return
+	}
+
+	http.Redirect(w, r, "/notifications", http.StatusSeeOther)
+}
@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"database/sql"                          // Provides SQL database support
+	"encoding/json"                         // Used to keep polls of held posts
+	"errors"                                // Used to report invalid poll forms and constraint violations
+	"fmt"                                   // Used to build redirect paths
+	models "literary-lions/internal/models" // Imports the models package for structured data types
+	"log"                                   // Used for logging errors
+	"net/http"                              // Provides HTTP client and server implementations
+	"strconv"                               // Used to parse poll and option IDs
+	"strings"                               // Used to split the options
+	"time"                                  // Used for closing dates
+
+	sqlite3 "github.com/mattn/go-sqlite3" // Used to recognise votes refused by the database
+)
+
+// Result visibility rules stored in "polls.results".
+const (
+	pollResultsAlways = "always" // Everyone sees the results at any time
+	pollResultsVoted  = "voted"  // Users see the results once they voted or the poll closed
+	pollResultsClosed = "closed" // Results stay hidden until the poll closes
+)
+
+// maxPollOptions is the largest number of options a poll can have.
+const maxPollOptions = 10
+
+// datetimeLocalLayout is the format of "datetime-local" form fields, such as a poll's closing date.
+const datetimeLocalLayout = "2006-01-02T15:04"
+
+// pollSpec is a poll as submitted with a new post, before it is stored.
+type pollSpec struct {
+	Question string     `json:"question"`
+	Options  []string   `json:"options"`
+	Multiple bool       `json:"multiple"`
+	Results  string     `json:"results"`
+	ClosesAt *time.Time `json:"closes_at,omitempty"`
+}
+
+// parsePollForm reads the optional poll of the new post form. It returns nil when the author
+// did not ask a question, and an error meant for the author when the poll is incomplete.
+func parsePollForm(r *http.Request) (*pollSpec, error) {
+	question := strings.TrimSpace(r.FormValue("poll_question"))
+	var options []string
+	for _, line := range strings.Split(r.FormValue("poll_options"), "\n") {
+		if option := strings.TrimSpace(line); option != "" && !containsString(options, option) {
+			options = append(options, option)
+		}
+	}
+	if question == "" {
+		if len(options) > 0 {
+			return nil, errors.New("The poll needs a question.")
+		}
+		return nil, nil
+	}
+	if len(options) < 2 || len(options) > maxPollOptions {
+		return nil, fmt.Errorf("The poll needs between 2 and %d different options, one per line.", maxPollOptions)
+	}
+
+	poll := &pollSpec{
+		Question: question,
+		Options:  options,
+		Multiple: r.FormValue("poll_multiple") == "on",
+		Results:  r.FormValue("poll_results"),
+	}
+	if poll.Results == "" {
+		poll.Results = pollResultsAlways
+	}
+	if poll.Results != pollResultsAlways && poll.Results != pollResultsVoted && poll.Results != pollResultsClosed {
+		return nil, errors.New("Unknown poll result visibility.")
+	}
+
+	if value := r.FormValue("poll_closes_at"); value != "" {
+		closesAt, err := time.ParseInLocation(datetimeLocalLayout, value, time.Local)
+		if err != nil || !closesAt.After(time.Now()) {
+			return nil, errors.New("The poll must close in the future.")
+		}
+		poll.ClosesAt = &closesAt
+	}
+	if poll.Results == pollResultsClosed && poll.ClosesAt == nil {
+		return nil, errors.New("Results shown after the poll closes need a closing date.")
+	}
+	return poll, nil
+}
+
+// createPoll attaches a poll to a post.
+func createPoll(tx *sql.Tx, postID int, poll *pollSpec) error {
+	var closesAt interface{}
+	if poll.ClosesAt != nil {
+		closesAt = *poll.ClosesAt
+	}
+	result, err := tx.Exec(`
+		INSERT INTO polls (post_id, question, multiple, results, closes_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		postID, poll.Question, poll.Multiple, poll.Results, closesAt, time.Now())
+	if err != nil {
+		return err
+	}
+	pollID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	for i, option := range poll.Options {
+		_, err := tx.Exec("INSERT INTO poll_options (poll_id, label, position) VALUES (?, ?, ?)", pollID, option, i)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodePoll turns the poll of a held post into the JSON kept in "held_submissions.poll".
+// Posts without a poll are stored as NULL.
+func encodePoll(poll *pollSpec) (interface{}, error) {
+	if poll == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(poll)
+	return string(encoded), err
+}
+
+// decodePoll reads the poll of a held post back; an empty value means the post has none.
+func decodePoll(encoded string) (*pollSpec, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+	poll := &pollSpec{}
+	return poll, json.Unmarshal([]byte(encoded), poll)
+}
+
+// loadPoll returns the poll attached to a post, or nil if it has none. Vote counts are only
+// filled in when the poll's visibility rule lets the viewer see them; the post's author always can.
+func loadPoll(db *sql.DB, postID, viewerID int, isAuthor bool) (*models.Poll, error) {
+	poll := &models.Poll{PostID: postID}
+	var closesAt sql.NullTime
+	err := db.QueryRow(`
+		SELECT id, question, multiple, results, closes_at FROM polls WHERE post_id = ?`, postID).
+		Scan(&poll.ID, &poll.Question, &poll.Multiple, &poll.Results, &closesAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if closesAt.Valid {
+		poll.ClosesAt = &closesAt.Time
+		poll.Closed = !closesAt.Time.After(time.Now())
+	}
+
+	err = db.QueryRow(`
+		SELECT COUNT(*), COALESCE(MAX(user_id = ?), 0) FROM poll_ballots WHERE poll_id = ?`,
+		viewerID, poll.ID).Scan(&poll.Voters, &poll.HasVoted)
+	if err != nil {
+		return nil, err
+	}
+
+	switch poll.Results {
+	case pollResultsVoted:
+		poll.ShowResults = isAuthor || poll.HasVoted || poll.Closed
+	case pollResultsClosed:
+		poll.ShowResults = isAuthor || poll.Closed
+	default:
+		poll.ShowResults = true
+	}
+
+	rows, err := db.Query(`
+		SELECT o.id, o.label, COUNT(v.user_id), COALESCE(MAX(v.user_id = ?), 0)
+		FROM poll_options o
+		LEFT JOIN poll_votes v ON v.option_id = o.id
+		WHERE o.poll_id = ?
+		GROUP BY o.id
+		ORDER BY o.position`, viewerID, poll.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		option := models.PollOption{PollID: poll.ID}
+		if err := rows.Scan(&option.ID, &option.Label, &option.Votes, &option.Chosen); err != nil {
+			return nil, err
+		}
+		if !poll.ShowResults {
+			option.Votes = 0
+		} else if poll.Voters > 0 {
+			option.Percent = option.Votes * 100 / poll.Voters
+		}
+		poll.Options = append(poll.Options, option)
+	}
+	if !poll.ShowResults {
+		poll.Voters = 0
+	}
+	return poll, rows.Err()
+}
+
+// PollVoteHandler records the logged-in user's vote on a poll. Each user votes once per poll;
+// the database refuses second ballots, options of other polls and several options on
+// single-choice polls.
+func PollVoteHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests change state.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	// Suspended and banned users cannot vote.
+	userID, ok := requireActiveUser(w, r, db)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Error parsing the form")
+		return
+	}
+	pollID, err := strconv.Atoi(r.FormValue("poll_id"))
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Incorrect data")
+		return
+	}
+	var optionIDs []int
+	for _, value := range r.Form["option_id"] {
+		optionID, err := strconv.Atoi(value)
+		if err != nil {
+			RenderErrorPage(w, r, db, http.StatusBadRequest, "Incorrect data")
+			return
+		}
+		optionIDs = append(optionIDs, optionID)
+	}
+	if len(optionIDs) == 0 {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Choose an option to vote")
+		return
+	}
+
+	var postID int
+	var closesAt sql.NullTime
+	// Polls of scheduled posts take votes once the post is published.
+	err = db.QueryRow(`
+		SELECT pl.post_id, pl.closes_at FROM polls pl
+		JOIN posts p ON p.id = pl.post_id
+		WHERE pl.id = ? AND `+publishedPost, pollID).Scan(&postID, &closesAt)
+	if err == sql.ErrNoRows {
+		RenderErrorPage(w, r, db, http.StatusNotFound, "Poll not found")
+		return
+	} else if err != nil {
+		log.Printf("Error loading poll: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error saving the vote")
+		return
+	}
+	if closesAt.Valid && !closesAt.Time.After(time.Now()) {
+		RenderErrorPage(w, r, db, http.StatusForbidden, "The poll is closed")
+		return
+	}
+
+	voted, err := castVote(db, pollID, userID, optionIDs)
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code == sqlite3.ErrConstraint {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Invalid choice for this poll")
+		return
+	} else if err != nil {
+		log.Printf("Error saving the vote: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error saving the vote")
+		return
+	}
+	if !voted {
+		RenderErrorPage(w, r, db, http.StatusConflict, "You have already voted in this poll")
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/post/%d#poll", postID), http.StatusSeeOther)
+}
+
+// castVote stores a ballot and its chosen options in one transaction. It reports false
+// without changing anything when the user had already voted.
+func castVote(db *sql.DB, pollID, userID int, optionIDs []int) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// INSERT OR IGNORE keeps a second ballot, even a concurrent one, from being counted.
+	result, err := tx.Exec("INSERT OR IGNORE INTO poll_ballots (poll_id, user_id, cast_at) VALUES (?, ?, ?)",
+		pollID, userID, time.Now())
+	if err != nil {
+		return false, err
+	}
+	if added, _ := result.RowsAffected(); added == 0 {
+		return false, nil
+	}
+	for _, optionID := range optionIDs {
+		_, err := tx.Exec("INSERT INTO poll_votes (poll_id, user_id, option_id) VALUES (?, ?, ?)", pollID, userID, optionID)
+		if err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
@@ -12,10 +12,10 @@ import (
 	"time"                           // Package for working with time and dates
 )
 
-// PostHandler handles requests to view and interact with a specific post.
+// PostHandler handles requests to view a specific post.
 func PostHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
-	// Only GET and POST methods are supported; others are rejected.
-	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+	// Only GET requests are supported; reactions go to "/react".
+	if r.Method != http.MethodGet {
 		// Render an error page for unsupported HTTP methods.
 		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
 		return
@@ -43,19 +43,30 @@ func PostHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 
 	// SQL query to retrieve post details along with its author and category.
 	query := `
-		SELECT p.id, p.user_id, u.username, p.title, p.body, p.category_id, c.name AS category_name, p.created_at
+		SELECT p.id, p.user_id, u.username, p.title, p.body, p.category_id, c.name AS category_name, p.created_at,
+		       p.is_question, c.is_question, COALESCE(p.accepted_comment_id, 0), COALESCE(p.pin_scope, ''), p.locked,
+		       p.publish_at, p.hidden
 		FROM posts p
 		JOIN users u ON p.user_id = u.id
 		JOIN categories c ON p.category_id = c.id
 		WHERE p.id = ?`
 	// Execute the query and populate the variables with the result.
+	var questionCategory bool
+	var publishAt sql.NullTime
 	err = db.QueryRow(query, postID).Scan(
 		&post.ID, &post.UserID, &author, &post.Title, &post.Body,
 		&post.CategoryID, &categoryName, &post.CreatedAt,
+		&post.IsQuestion, &questionCategory, &post.AcceptedID, &post.PinScope, &post.Locked,
+		&publishAt, &post.Hidden,
 	)
 	if err != nil {
 		// Handle errors for no rows or general query issues.
 		if err == sql.ErrNoRows {
+			// Posts merged into another thread send their old URL on to it.
+			if targetID, ok := mergedInto(db, postID); ok {
+				http.Redirect(w, r, fmt.Sprintf("/post/%d", targetID), http.StatusMovedPermanently)
+				return
+			}
 			RenderErrorPage(w, r, db, http.StatusNotFound, "Post not found")
 		} else {
 			log.Printf("Error extracting the post: %v", err)
@@ -63,10 +74,14 @@ func PostHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 		}
 		return
 	}
+	if publishAt.Valid {
+		post.PublishAt = &publishAt.Time
+	}
 
 	// Extract the "error" query parameter, if present, from the URL.
 	queryURL := r.URL.Query()
 	errorMessage := queryURL.Get("error")
+	notice := queryURL.Get("notice")
 
 	// Check if the user is logged in by inspecting the session cookie.
 	var user *models.User
@@ -85,48 +100,18 @@ func PostHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 		}
 	}
 
-	// Handle POST requests for liking/disliking posts or comments.
-	if r.Method == http.MethodPost {
-		// Determine the target type (post or comment) and if it's a like.
-		targetType := r.FormValue("target_type")
-		isLike := r.FormValue("is_like") == "true"
-
-		// Validate the comment body if applicable.
-		body := strings.TrimSpace(r.FormValue("body"))
-		if body == "" {
-			RenderPostWithError(w, r, db, postID, "Comment cannot be empty")
-			return
-		}
-
-		// Process likes/dislikes for the post.
-		if targetType == "post" {
-			_, err = db.Exec(`
-				INSERT OR REPLACE INTO likes_dislikes (user_id, target_id, target_type, is_like, created_at)
-				VALUES (?, ?, ?, ?, ?)`,
-				user.ID, postID, "post", isLike, time.Now())
-			if err != nil {
-				log.Printf("Error when adding/updating like/dislike for the post: %v", err)
-			}
-		}
-
-		// Handle likes/dislikes for a comment, if a comment ID is provided.
-		commentID := r.FormValue("comment_id")
-		if commentID != "" {
-			commentIDInt, _ := strconv.Atoi(commentID)
-			if targetType == "comment" {
-				_, err = db.Exec(`
-					INSERT INTO likes_dislikes (user_id, target_id, target_type, is_like, created_at)
-					VALUES (?, ?, ?, ?, ?)
-					ON DUPLICATE KEY UPDATE is_like = ?, created_at = ?`,
-					user.ID, commentIDInt, "comment", isLike, time.Now(), isLike, time.Now())
-				if err != nil {
-					log.Printf("Error when adding/updating like/dislike for comments: %v", err)
-				}
-			}
-		}
-
-		// Redirect back to the same post page to refresh and show updated data.
-		http.Redirect(w, r, r.URL.String(), http.StatusSeeOther)
+	// Posts by shadowbanned users are visible only to their authors.
+	viewer := 0
+	if user != nil {
+		viewer = user.ID
+	}
+	if post.UserID != viewer && isShadowbanned(db, post.UserID) {
+		RenderErrorPage(w, r, db, http.StatusNotFound, "Post not found")
+		return
+	}
+	// Scheduled posts are previews for their authors and admins until they are published.
+	if !canPreview(db, post, viewer) {
+		RenderErrorPage(w, r, db, http.StatusNotFound, "Post not found")
 		return
 	}
 
@@ -138,16 +123,16 @@ func PostHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 	// The query joins the "comments" and "users" tables on "user_id",
 	// filters by "post_id", and orders the results by creation time in descending order.
 	commentQuery := `
-SELECT c.id, c.post_id, c.user_id, u.username, c.body, c.created_at
+SELECT c.id, c.post_id, c.user_id, u.username, c.body, c.created_at, c.hidden
 FROM comments c
 JOIN users u ON c.user_id = u.id
-WHERE c.post_id = ?
+WHERE c.post_id = ? AND ` + visibleAuthor("c.user_id") + `
 ORDER BY c.created_at DESC
 `
 
 	// Execute the query using the database connection and the provided post ID.
-	// Retrieve the rows matching the query.
-	rows, err := db.Query(commentQuery, postID)
+	// Retrieve the rows matching the query; comments by shadowbanned users are hidden from everyone else.
+	rows, err := db.Query(commentQuery, postID, viewer)
 	if err != nil {
 		// Log the error and render an error page with a 500 status code if the query fails.
 		log.Printf("Error extracting comments: %v", err)
@@ -161,7 +146,7 @@ ORDER BY c.created_at DESC
 	for rows.Next() {
 		var comment models.Comment
 		// Map the columns of the current row to the fields of the Comment model.
-		if err := rows.Scan(&comment.ID, &comment.PostID, &comment.UserID, &comment.Username, &comment.Body, &comment.CreatedAt); err != nil {
+		if err := rows.Scan(&comment.ID, &comment.PostID, &comment.UserID, &comment.Username, &comment.Body, &comment.CreatedAt, &comment.Hidden); err != nil {
 			// Log the error and render an error page if scanning fails.
 			log.Printf("Error reading comments: %v", err)
 			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading comments")
@@ -171,47 +156,25 @@ ORDER BY c.created_at DESC
 		comments = append(comments, comment)
 	}
 
-	// Fetch like/dislike counts for the post and comments
-	// Call CountLikes to get the number of likes for the post.
-	postLikes, err := CountLikes(db, postID, "post")
-	if err != nil {
-		// Log an error if the count query for likes fails.
-		log.Printf("Error getting amount of likes for the post: %v", err)
+	// The accepted answer of a question is pinned under the post.
+	var acceptedAnswer *models.Comment
+	for i := range comments {
+		if comments[i].ID == post.AcceptedID {
+			acceptedAnswer = &comments[i]
+		}
 	}
 
-	// Call CountDislikes to get the number of dislikes for the post.
-	postDislikes, err := CountDislikes(db, postID, "post")
+	// Fetch the reactions to the post and its comments, with who gave them.
+	postReactions, commentReactions, err := threadReactions(db, postID, viewer)
 	if err != nil {
-		// Log an error if the count query for dislikes fails.
-		log.Printf("Error getting amount of dislikes for the post: %v", err)
+		// Log the error but still show the thread, with empty reaction counts.
+		log.Printf("Error loading reactions: %v", err)
+		postReactions, commentReactions = newReactionCounts(), make(map[int][]models.ReactionCount)
 	}
-
-	// Get like/dislike counts for each comment
-	// Initialize a map to store like and dislike counts for each comment.
-	commentCounts := make(map[int]models.LikeDislikeCount)
-
-	// Loop through the retrieved comments.
+	// Comments nobody reacted to still get their buttons.
 	for _, comment := range comments {
-		// Call CountLikes for each comment to get the number of likes.
-		commentLikes, err := CountLikes(db, comment.ID, "comment")
-		if err != nil {
-			// Log an error if the count query for likes fails, but continue processing other comments.
-			log.Printf("Error getting amount of likes for the comments %d: %v", comment.ID, err)
-			continue
-		}
-
-		// Call CountDislikes for each comment to get the number of dislikes.
-		commentDislikes, err := CountDislikes(db, comment.ID, "comment")
-		if err != nil {
-			// Log an error if the count query for dislikes fails, but continue processing other comments.
-			log.Printf("Error getting amount of dislikes for the comments %d: %v", comment.ID, err)
-			continue
-		}
-
-		// Store the like and dislike counts in the map using the comment ID as the key.
-		commentCounts[comment.ID] = models.LikeDislikeCount{
-			Likes:    commentLikes,
-			Dislikes: commentDislikes,
+		if commentReactions[comment.ID] == nil {
+			commentReactions[comment.ID] = newReactionCounts()
 		}
 	}
 
@@ -252,23 +215,100 @@ ORDER BY c.created_at DESC
 		return
 	}
 
-	// Render page with updated like/dislike counts
+	// Check whether the viewer follows the post's author to show the right button.
+	followsAuthor := false
+	if user != nil && user.ID != post.UserID {
+		followsAuthor, err = isFollowingUser(db, user.ID, post.UserID)
+		if err != nil {
+			log.Printf("Error checking follow: %v", err)
+		}
+	}
+
+	// Load the viewer's reading lists for the bookmark form.
+	var bookmarkLists []models.BookmarkList
+	if user != nil {
+		bookmarkLists, err = loadBookmarkLists(db, user.ID, false)
+		if err != nil {
+			log.Printf("Error loading bookmark lists: %v", err)
+		}
+	}
+
+	// Work out which comments are new since the last visit, then mark the whole thread as read.
+	lastReadID, firstUnreadID, unreadCount := 0, 0, 0
+	if user != nil {
+		var visited bool
+		lastReadID, visited, err = lastReadComment(db, user.ID, postID)
+		if err != nil {
+			log.Printf("Error loading read marker: %v", err)
+		}
+		// On a first visit the whole thread is new, so there is nothing to jump to.
+		if visited {
+			firstUnreadID, unreadCount = firstUnreadComment(comments, user.ID, lastReadID)
+		}
+		if err := markPostRead(db, user.ID, postID); err != nil {
+			log.Printf("Error saving read marker: %v", err)
+		}
+	}
+
+	// Reputation is shown next to every username in the thread.
+	reputation, err := threadReputation(db, postID)
+	if err != nil {
+		log.Printf("Error loading reputation: %v", err)
+	}
+
+	// Load the poll attached to the post, if any.
+	poll, err := loadPoll(db, postID, viewer, viewer == post.UserID)
+	if err != nil {
+		log.Printf("Error loading poll: %v", err)
+	}
+
+	// Load the comments that were split off into other threads.
+	moved, err := loadMovedComments(db, postID)
+	if err != nil {
+		log.Printf("Error loading moved comments: %v", err)
+	}
+
+	// Content hidden by reports is kept from other members until a moderator reviews it.
+	moderatorView := user != nil && isModerator(db, user.ID)
+	if post.Hidden && post.UserID != viewer && !moderatorView {
+		post.Body = ""
+	}
+	for i := range comments {
+		if comments[i].Hidden && comments[i].UserID != viewer && !moderatorView {
+			comments[i].Body = ""
+		}
+	}
+
+	// Render page with updated reaction counts
 	// Create a PostPageData struct to hold all data required for rendering the page.
 	pageData := models.PostPageData{
-		Post:          post,
-		User:          user,
-		Comments:      comments,
-		PostLikes:     postLikes,
-		PostDislikes:  postDislikes,
-		CommentCounts: commentCounts,
-		Author:        author,
-		Category:      categoryName,
-		Categories:    categories,
-		ErrorMessage:  errorMessage,
+		Post:             post,
+		User:             user,
+		Comments:         comments,
+		Reactions:        postReactions,
+		CommentReactions: commentReactions,
+		Author:           author,
+		Category:         categoryName,
+		Categories:       categories,
+		ErrorMessage:     errorMessage,
+		Notice:           notice,
+		FollowsAuthor:    followsAuthor,
+		BookmarkLists:    bookmarkLists,
+		LastReadID:       lastReadID,
+		FirstUnreadID:    firstUnreadID,
+		UnreadCount:      unreadCount,
+		Reputation:       reputation,
+		Poll:             poll,
+		QuestionCategory: questionCategory,
+		AcceptedAnswer:   acceptedAnswer,
+		IsModerator:      moderatorView,
+		Moved:            moved,
 	}
 
 	// Parse the required HTML templates for rendering the page.
-	tmpl, err := template.ParseFiles("assets/template/header.html", "assets/template/post.html")
+	// The "mentions" function turns @username into profile links in the post and its comments.
+	tmpl, err := template.New("post").Funcs(template.FuncMap{"mentions": mentionLinker(db)}).
+		ParseFiles("assets/template/header.html", "assets/template/post.html")
 	if err != nil {
 		// Log the error and render an error page if template parsing fails.
 		log.Printf("Error loading template: %v", err)
@@ -345,46 +385,71 @@ func AllPostsHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 	var rows *sql.Rows
 	var err error
 
+	// "?filter=unanswered" keeps only questions without an accepted answer.
+	filter := r.URL.Query().Get("filter")
+	if filter != "" && filter != "unanswered" {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Unknown filter")
+		return
+	}
+
+	// Posts by shadowbanned users are shown only to their authors, and posts hidden by reports
+	// only to their authors and moderators.
+	viewer := viewerID(r, db)
+	visible := visibleAuthor("p.user_id") + " AND " + unhidden("p") + " AND " + publishedPost
+	if filter == "unanswered" {
+		visible += " AND " + unansweredQuestion
+	}
+	order := pinnedOrder(categoryIDStr != "")
+
 	// Fetch posts based on the combination of provided parameters
 	if categoryIDStr != "" && userIDStr != "" {
 		// Fetch posts by both category and user, joining users and categories tables
 		rows, err = db.Query(`
-			SELECT p.id, p.user_id, u.username, p.title, p.body, p.category_id, c.name AS category_name, p.created_at
+			SELECT p.id, p.user_id, u.username, p.title, p.body, p.category_id, c.name AS category_name, p.created_at,
+			       p.likes, p.dislikes, p.comment_count, p.last_activity_at,
+			       `+questionPost+`, COALESCE(p.accepted_comment_id, 0), COALESCE(p.pin_scope, ''), p.locked
 			FROM posts p
 			JOIN users u ON p.user_id = u.id
 			JOIN categories c ON p.category_id = c.id
-			WHERE p.category_id = ? AND p.user_id = ?
-			ORDER BY p.created_at DESC
-		`, categoryID, userID)
+			WHERE p.category_id = ? AND p.user_id = ? AND `+visible+`
+			`+order+`
+		`, categoryID, userID, viewer, viewer)
 	} else if categoryIDStr != "" {
 		// Fetch posts by category, joining users and categories tables
 		rows, err = db.Query(`
-			SELECT p.id, p.user_id, u.username, p.title, p.body, p.category_id, c.name AS category_name, p.created_at
+			SELECT p.id, p.user_id, u.username, p.title, p.body, p.category_id, c.name AS category_name, p.created_at,
+			       p.likes, p.dislikes, p.comment_count, p.last_activity_at,
+			       `+questionPost+`, COALESCE(p.accepted_comment_id, 0), COALESCE(p.pin_scope, ''), p.locked
 			FROM posts p
 			JOIN users u ON p.user_id = u.id
 			JOIN categories c ON p.category_id = c.id
-			WHERE p.category_id = ?
-			ORDER BY p.created_at DESC
-		`, categoryID)
+			WHERE p.category_id = ? AND `+visible+`
+			`+order+`
+		`, categoryID, viewer, viewer)
 	} else if userIDStr != "" {
 		// Fetch posts by user, joining users and categories tables
 		rows, err = db.Query(`
-			SELECT p.id, p.user_id, u.username, p.title, p.body, p.category_id, c.name AS category_name, p.created_at
+			SELECT p.id, p.user_id, u.username, p.title, p.body, p.category_id, c.name AS category_name, p.created_at,
+			       p.likes, p.dislikes, p.comment_count, p.last_activity_at,
+			       `+questionPost+`, COALESCE(p.accepted_comment_id, 0), COALESCE(p.pin_scope, ''), p.locked
 			FROM posts p
 			JOIN users u ON p.user_id = u.id
 			JOIN categories c ON p.category_id = c.id
-			WHERE p.user_id = ?
-			ORDER BY p.created_at DESC
-		`, userID)
+			WHERE p.user_id = ? AND `+visible+`
+			`+order+`
+		`, userID, viewer, viewer)
 	} else {
 		// Fetch all posts, joining users and categories tables
 		rows, err = db.Query(`
-			SELECT p.id, p.user_id, u.username, p.title, p.body, p.category_id, c.name AS category_name, p.created_at
+			SELECT p.id, p.user_id, u.username, p.title, p.body, p.category_id, c.name AS category_name, p.created_at,
+			       p.likes, p.dislikes, p.comment_count, p.last_activity_at,
+			       `+questionPost+`, COALESCE(p.accepted_comment_id, 0), COALESCE(p.pin_scope, ''), p.locked
 			FROM posts p
 			JOIN users u ON p.user_id = u.id
 			JOIN categories c ON p.category_id = c.id
-			ORDER BY p.created_at DESC
-		`)
+			WHERE `+visible+`
+			`+order+`
+		`, viewer, viewer)
 	}
 
 	// Handle any errors that occurred during the query execution
@@ -405,6 +470,8 @@ func AllPostsHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 		var author, categoryName string
 		if err := rows.Scan(
 			&post.ID, &post.UserID, &author, &post.Title, &post.Body, &post.CategoryID, &categoryName, &post.CreatedAt,
+			&post.Likes, &post.Dislikes, &post.CommentCount, &post.LastActivity,
+			&post.IsQuestion, &post.AcceptedID, &post.PinScope, &post.Locked,
 		); err != nil {
 			// Handle scanning errors and respond with "500 Internal Server Error"
 			log.Printf("Error extracting post's data: %v", err)
@@ -443,6 +510,13 @@ func AllPostsHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 		}
 	}
 
+	// Mark posts with comments the user has not read yet
+	if user != nil {
+		if err := markUnread(db, user.ID, posts); err != nil {
+			log.Printf("Error loading unread counts: %v", err) // Unread markers are optional, so only log
+		}
+	}
+
 	// Fetch categories from the database
 	// Query the database to retrieve all categories, fetching their ID and name.
 	rowsCategory, err := db.Query("SELECT id, name FROM categories")
@@ -487,6 +561,8 @@ func AllPostsHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 		Posts:      posts,      // Pass the posts data (assumed to be defined elsewhere).
 		User:       user,       // Include the current user data.
 		Categories: categories, // Add the fetched categories.
+		Filter:     filter,     // Remember the active filter for the filter links.
+		CategoryID: categoryID, // Keep the category when switching filters.
 	}
 
 	// Parse HTML templates required to render the posts page.
@@ -583,10 +659,31 @@ func NewPostHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 			return
 		}
 
-		// Prepare the data for the new post page, including user and categories.
+		// Continue a saved draft when the author opens one.
+		var draft *models.Draft
+		if value := r.URL.Query().Get("draft"); value != "" && user != nil {
+			draftID, err := strconv.Atoi(value)
+			if err != nil {
+				RenderErrorPage(w, r, db, http.StatusBadRequest, "Incorrect ID of the draft")
+				return
+			}
+			draft, err = loadDraft(db, draftID, user.ID)
+			if err != nil {
+				log.Printf("Error loading the draft: %v", err)
+				RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading the draft")
+				return
+			}
+			if draft == nil {
+				RenderErrorPage(w, r, db, http.StatusNotFound, "Draft not found")
+				return
+			}
+		}
+
+		// Prepare the data for the new post page, including user, categories and the draft.
 		pageData := models.NewPostPageData{
 			User:       user,
 			Categories: categories,
+			Draft:      draft,
 		}
 
 		// Load the HTML templates for rendering the new post page.
@@ -614,25 +711,14 @@ func NewPostHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 
 	// Check if the HTTP method is POST (used for submitting a new post).
 	if r.Method == http.MethodPost {
-		// Attempt to retrieve the "session_token" cookie to authenticate the user.
-		cookie, err := r.Cookie("session_token")
-		if err != nil {
-			// If no valid session, render an unauthorized error page.
-			RenderErrorPage(w, r, db, http.StatusUnauthorized, "User is not authorised")
-			return
-		}
-
-		var userID int
-		// Query the database to get the user ID associated with the session token.
-		err = db.QueryRow("SELECT user_id FROM sessions WHERE session_token = ?", cookie.Value).Scan(&userID)
-		if err != nil {
-			// If the session is invalid, render an unauthorized error page.
-			RenderErrorPage(w, r, db, http.StatusUnauthorized, "User is not authorised")
+		// Authenticate the user; suspended and banned users cannot post.
+		userID, ok := requireActiveUser(w, r, db)
+		if !ok {
 			return
 		}
 
 		// Parse the form data submitted with the POST request.
-		err = r.ParseForm()
+		err := r.ParseForm()
 		if err != nil {
 			// If the form cannot be parsed, render a bad request error page.
 			RenderErrorPage(w, r, db, http.StatusBadRequest, "Error parsing the form")
@@ -749,8 +835,58 @@ func NewPostHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 			return
 		}
 
-		result, err := db.Exec("INSERT INTO posts (user_id, title, body, category_id, created_at) VALUES (?, ?, ?, ?, ?)",
-			userID, title, body, categoryID, time.Now())
+		// Read the optional poll; an incomplete poll sends the author back to the form.
+		poll, err := parsePollForm(r)
+		if err != nil {
+			renderNewPostPage(w, r, db, userID, err.Error(), "")
+			return
+		}
+
+		// Read the optional publishing date of a scheduled post.
+		publishAt, err := parsePublishAt(r)
+		if err != nil {
+			renderNewPostPage(w, r, db, userID, err.Error(), "")
+			return
+		}
+
+		// Apply the word filters and spam heuristics before publishing.
+		var screen screening
+		title, body, screen, err = screenSubmission(db, userID, title, body)
+		if err != nil {
+			log.Printf("Error screening the post: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error creating the post")
+			return
+		}
+		switch screen.Verdict {
+		case filterReject:
+			renderNewPostPage(w, r, db, userID, "Your post was not published. "+screen.Reason+".", "")
+			return
+		case filterHold:
+			if err := holdSubmission(db, userID, "post", 0, categoryID, title, body, poll, publishAt, screen.Reason); err != nil {
+				log.Printf("Error holding the post: %v", err)
+				RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error creating the post")
+				return
+			}
+			discardDraft(db, r, userID)
+			renderNewPostPage(w, r, db, userID, "", "Your post was sent to the moderators for review and will appear once approved.")
+			return
+		}
+
+		// The post and its poll are created together or not at all.
+		tx, err := db.Begin()
+		if err != nil {
+			log.Printf("Error starting transaction: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error creating the post")
+			return
+		}
+		defer tx.Rollback()
+
+		var scheduledAt interface{}
+		if publishAt != nil {
+			scheduledAt = *publishAt
+		}
+		result, err := tx.Exec("INSERT INTO posts (user_id, title, body, category_id, created_at, publish_at) VALUES (?, ?, ?, ?, ?, ?)",
+			userID, title, body, categoryID, time.Now(), scheduledAt)
 		// Insert the new post into the `posts` table, associating it with the user and category.
 
 		if err != nil {
@@ -769,209 +905,85 @@ func NewPostHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 			return
 		}
 
-		// Redirect the user to the page displaying the newly created post.
-		http.Redirect(w, r, fmt.Sprintf("/post/%d", postID), http.StatusSeeOther)
-	}
-}
-
-func RenderPostWithError(w http.ResponseWriter, r *http.Request, db *sql.DB, postID int, errorMessage string) {
-	// Declare variables to hold data for the post and related information.
-	var author string       // To store the username of the post's author.
-	var categoryName string // To store the name of the post's category.
-	var post models.Post    // Struct to hold the main post data.
-
-	// SQL query to retrieve the post details, including author and category information.
-	query := `
-        SELECT p.id, p.user_id, u.username, p.title, p.body, p.category_id, c.name AS category_name, p.created_at
-        FROM posts p
-        JOIN users u ON p.user_id = u.id
-        JOIN categories c ON p.category_id = c.id
-        WHERE p.id = ?`
-
-	// Execute the query and scan the results into the respective variables.
-	err := db.QueryRow(query, postID).Scan(
-		&post.ID, &post.UserID, &author, &post.Title, &post.Body,
-		&post.CategoryID, &categoryName, &post.CreatedAt,
-	)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			// If no rows are found, render a 404 error page.
-			RenderErrorPage(w, r, db, http.StatusNotFound, "Post not found")
-		} else {
-			// For any other error, log it and render a 500 error page.
-			log.Printf("Error extracting the post: %v", err)
-			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading post")
+		if poll != nil {
+			err = createPoll(tx, int(postID), poll)
 		}
-		return
-	}
-
-	// Check if a user is logged in by validating the session cookie.
-	var user *models.User // Pointer to a user struct to hold the logged-in user details.
-	cookie, err := r.Cookie("session_token")
-	if err == nil {
-		var userID int // Variable to store the user ID from the session.
-		err = db.QueryRow("SELECT user_id FROM sessions WHERE session_token = ?", cookie.Value).Scan(&userID)
 		if err == nil {
-			user = &models.User{} // Initialize the user struct.
-			// Fetch user details based on the user ID from the session.
-			err = db.QueryRow("SELECT id, username FROM users WHERE id = ?", userID).Scan(&user.ID, &user.Username)
-			if err != nil {
-				log.Printf("Error getting the user: %v", err)
-			}
+			err = tx.Commit()
+		}
+		if err != nil {
+			log.Printf("Error creating the post: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error creating the post")
+			return
 		}
-	}
 
-	// Handle form submissions for likes/dislikes.
-	if r.Method == http.MethodPost {
-		targetType := r.FormValue("target_type")   // Target type (post or comment).
-		isLike := r.FormValue("is_like") == "true" // Determine if it's a like.
+		// The draft the post was written in is no longer needed.
+		discardDraft(db, r, userID)
 
-		body := strings.TrimSpace(r.FormValue("body")) // Get and trim the comment body.
-		if body == "" {
-			// If the comment body is empty, re-render the post with an error message.
-			RenderPostWithError(w, r, db, postID, "Comment cannot be empty")
+		// A scheduled post gets its follow-up when it is published; until then the author sees a preview.
+		if publishAt != nil {
+			http.Redirect(w, r, fmt.Sprintf("/post/%d", postID), http.StatusSeeOther)
 			return
 		}
 
-		// Handle like/dislike for a post.
-		if targetType == "post" {
-			_, err = db.Exec(`
-            INSERT OR REPLACE INTO likes_dislikes (user_id, target_id, target_type, is_like, created_at)
-            VALUES (?, ?, ?, ?, ?)`,
-				user.ID, postID, "post", isLike, time.Now())
-			if err != nil {
-				log.Printf("Error when adding/updating like/dislike for the post: %v", err)
-			}
-		}
+		// Award any badges the new post earns.
+		awardBadges(db, userID, badgeEventPost)
 
-		// Handle like/dislike for a comment, if provided.
-		commentID := r.FormValue("comment_id")
-		if commentID != "" {
-			commentIDInt, _ := strconv.Atoi(commentID) // Convert comment ID to an integer.
-			if targetType == "comment" {
-				_, err = db.Exec(`
-                    INSERT INTO likes_dislikes (user_id, target_id, target_type, is_like, created_at)
-                    VALUES (?, ?, ?, ?, ?)
-                    ON DUPLICATE KEY UPDATE is_like = ?, created_at = ?`,
-					user.ID, commentIDInt, "comment", isLike, time.Now(), isLike, time.Now())
-				if err != nil {
-					log.Printf("Error when adding/updating like/dislike for comments: %v", err)
-				}
-			}
+		// Link and notify any users mentioned with "@username" in the post,
+		// unless the author is shadowbanned and nobody else can see it.
+		if !isShadowbanned(db, userID) {
+			recordMentions(db, userID, "post", int(postID), int(postID), body)
 		}
 
-		// Redirect back to the same post page to refresh and display updated information.
-		http.Redirect(w, r, r.URL.String(), http.StatusSeeOther)
-		return
+		// Redirect the user to the page displaying the newly created post.
+		http.Redirect(w, r, fmt.Sprintf("/post/%d", postID), http.StatusSeeOther)
 	}
+}
 
-	// Retrieve comments for the post, including the author's username for each comment.
-	var comments []models.Comment // Slice to store comments for the post.
-	commentQuery := `
-    SELECT c.id, c.post_id, c.user_id, u.username, c.body, c.created_at
-    FROM comments c
-    JOIN users u ON c.user_id = u.id
-    WHERE c.post_id = ?
-    ORDER BY c.created_at DESC`
-	rows, err := db.Query(commentQuery, postID)
+// renderNewPostPage shows the new post form again with an error or an informational notice.
+func renderNewPostPage(w http.ResponseWriter, r *http.Request, db *sql.DB, userID int, errorMessage, notice string) {
+	user := &models.User{}
+	err := db.QueryRow("SELECT id, username FROM users WHERE id = ?", userID).Scan(&user.ID, &user.Username)
 	if err != nil {
-		log.Printf("Error extracting comments: %v", err)
-		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading comments")
+		log.Printf("Error getting the user: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading user")
 		return
 	}
-	defer rows.Close() // Ensure rows are closed to free resources.
-
-	// Iterate through each row and populate the comments slice.
-	for rows.Next() {
-		var comment models.Comment // Temporary variable to hold comment data.
-		if err := rows.Scan(&comment.ID, &comment.PostID, &comment.UserID, &comment.Username, &comment.Body, &comment.CreatedAt); err != nil {
-			log.Printf("Error reading comments: %v", err)
-			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading comments")
-			return
-		}
-		comments = append(comments, comment) // Add the comment to the slice.
-	}
 
-	// Fetch like/dislike counts for post and comments
-	postLikes, err := CountLikes(db, postID, "post") // Call the CountLikes function to get the number of likes for a specific post, passing the database connection, the post's ID, and the type ("post").
-	if err != nil {                                  // Check if there was an error during the execution of CountLikes.
-		log.Printf("Error getting amount of likes for the post: %v", err) // Log the error message, including details of the error for debugging.
-	}
-
-	postDislikes, err := CountDislikes(db, postID, "post") // Call the CountDislikes function to get the number of dislikes for the same post.
-	if err != nil {                                        // Check if there was an error during the execution of CountDislikes.
-		log.Printf("Error getting amount of dislikes for the post: %v", err) // Log the error message with relevant details.
+	categories, err := loadCategories(db)
+	if err != nil {
+		log.Printf("Error loading categories: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading categories")
+		return
 	}
 
-	// Get like/dislike counts for each comment
-	commentCounts := make(map[int]models.LikeDislikeCount) // Initialize a map to store the like/dislike counts for each comment, using comment IDs as keys.
-	for _, comment := range comments {                     // Loop through the list of comments for the post.
-		commentLikes, err := CountLikes(db, comment.ID, "comment") // Get the number of likes for the current comment.
-		if err != nil {                                            // Check if there was an error in getting the like count.
-			log.Printf("Error getting amount of likes for the comments %d: %v", comment.ID, err) // Log the error with the comment ID for context.
-			continue                                                                             // Skip further processing for this comment due to the error.
-		}
-		commentDislikes, err := CountDislikes(db, comment.ID, "comment") // Get the number of dislikes for the current comment.
-		if err != nil {                                                  // Check if there was an error in getting the dislike count.
-			log.Printf("Error getting amount of dislikes for the comments %d: %v", comment.ID, err) // Log the error with the comment ID for context.
-			continue                                                                                // Skip further processing for this comment due to the error.
-		}
-		commentCounts[comment.ID] = models.LikeDislikeCount{ // Add the like and dislike counts for the current comment to the map.
-			Likes:    commentLikes,    // Set the number of likes.
-			Dislikes: commentDislikes, // Set the number of dislikes.
-		}
+	tmpl, err := template.ParseFiles("assets/template/header.html", "assets/template/new_post.html")
+	if err != nil {
+		log.Printf("Error loading template: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading template")
+		return
 	}
 
-	// Fetch categories from the database
-	rowsCategory, err := db.Query("SELECT id, name FROM categories") // Execute a SQL query to fetch all categories from the database.
-	if err != nil {                                                  // Check if there was an error executing the query.
-		log.Printf("Error loading categories: %v", err)                                       // Log the error with details for debugging.
-		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading categories") // Render an error page with HTTP 500 status code and a descriptive message.
-		return                                                                                // Exit the function to prevent further execution.
-	}
-	defer rowsCategory.Close() // Ensure the database rows are closed after processing, to free resources.
-
-	var categories []models.Category // Initialize a slice to store the fetched categories.
-	for rowsCategory.Next() {        // Iterate through each row in the query result.
-		var category models.Category                                            // Create a variable to hold the current category data.
-		if err := rowsCategory.Scan(&category.ID, &category.Name); err != nil { // Read the current row's data into the category variable.
-			log.Printf("Error reading categories: %v", err)                                       // Log any errors encountered during the scan.
-			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading categories") // Render an error page and exit.
-			return
+	// Keep the author's draft in the form, so a rejected post is not lost.
+	var draft *models.Draft
+	if draftID, err := strconv.Atoi(r.FormValue("draft_id")); err == nil {
+		draft, err = loadDraft(db, draftID, userID)
+		if err != nil {
+			log.Printf("Error loading the draft: %v", err)
 		}
-		categories = append(categories, category) // Add the successfully read category to the categories slice.
 	}
 
-	if err := rowsCategory.Err(); err != nil { // Check for any errors that occurred during row iteration.
-		log.Printf("Error parsing categories: %v", err)                                       // Log the error details for debugging.
-		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading categories") // Render an error page and exit.
-		return
+	pageData := models.NewPostPageData{
+		User:         user,
+		Categories:   categories,
+		ErrorMessage: errorMessage,
+		Notice:       notice,
+		Draft:        draft,
 	}
 
-	// Add the error message to the page data
-	pageData := models.PostPageData{ // Populate the pageData structure with all relevant information for rendering the page.
-		Post:          post,          // The post being viewed.
-		User:          user,          // The user viewing the post.
-		Comments:      comments,      // The list of comments associated with the post.
-		PostLikes:     postLikes,     // The total number of likes for the post.
-		PostDislikes:  postDislikes,  // The total number of dislikes for the post.
-		CommentCounts: commentCounts, // Like and dislike counts for each comment.
-		Author:        author,        // The author of the post.
-		Category:      categoryName,  // The category associated with the post.
-		Categories:    categories,    // The list of all categories.
-		ErrorMessage:  errorMessage,  // Any error message to display on the page.
-	}
-
-	tmpl, err := template.ParseFiles("assets/template/header.html", "assets/template/post.html") // Parse the HTML templates for rendering the page.
-	if err != nil {                                                                              // Check if there was an error parsing the templates.
-		log.Printf("Error loading template: %v", err)                                       // Log the error details for debugging.
-		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading template") // Render an error page and exit.
-		return
-	}
-
-	w.Header().Set("Content-Type", "text/html")                       // Set the response content type to HTML.
-	if err := tmpl.ExecuteTemplate(w, "post", pageData); err != nil { // Render the "post" template with the provided pageData.
-		log.Printf("Rendering error: %v", err)                                            // Log any errors encountered during rendering.
-		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error rendering page") // Render an error page and exit.
+	w.Header().Set("Content-Type", "text/html")
+	if err := tmpl.ExecuteTemplate(w, "new_post", pageData); err != nil {
+		log.Printf("Rendering error: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Rendering page error")
 	}
 }
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"database/sql"                          // Provides SQL database support
+	"html/template"                         // Used for rendering HTML templates
+	models "literary-lions/internal/models" // Imports the models package for structured data types
+	"log"                                   // Used for logging errors
+	"net/http"                              // Provides HTTP client and server implementations
+	"strings"                               // Used to extract the username from the path
+)
+
+// recentActivityLimit is the number of posts and comments listed on a public profile.
+const recentActivityLimit = 10
+
+// Profile visibility levels stored in "profile_settings.visibility".
+const (
+	visibilityPublic  = "public"  // Anyone can see the profile
+	visibilityMembers = "members" // Only logged-in users can see the profile
+	visibilityPrivate = "private" // Only the owner can see the profile
+)
+
+// PublicProfileHandler renders "/u/{username}", the public profile of a user, honouring their privacy settings.
+func PublicProfileHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only GET requests are supported.
+	if r.Method != http.MethodGet {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	username := strings.TrimPrefix(r.URL.Path, "/u/")
+	if username == "" || strings.Contains(username, "/") {
+		RenderErrorPage(w, r, db, http.StatusNotFound, "Page not found")
+		return
+	}
+
+	// The viewer may be anonymous.
+	var user *models.User
+	if viewerID, err := GetUserIDFromSession(r, db); err == nil {
+		user = &models.User{}
+		err = db.QueryRow("SELECT id, username FROM users WHERE id = ?", viewerID).Scan(&user.ID, &user.Username)
+		if err != nil {
+			log.Printf("Error getting the user: %v", err)
+			user = nil
+		}
+	}
+
+	profile := &models.User{}
+	err := db.QueryRow(`
+		SELECT id, username, created_at, COALESCE(bio, ''), COALESCE(profile_image, 'assets/static/images/placeholder.png')
+		FROM users WHERE username = ?`, username).
+		Scan(&profile.ID, &profile.Username, &profile.CreatedAt, &profile.Bio, &profile.ProfImage)
+	if err == sql.ErrNoRows {
+		RenderErrorPage(w, r, db, http.StatusNotFound, "User is not found")
+		return
+	} else if err != nil {
+		log.Printf("Error getting the profile: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading profile")
+		return
+	}
+
+	// An empty bio is treated as no bio at all.
+	if profile.Bio != nil && *profile.Bio == "" {
+		profile.Bio = nil
+	}
+
+	settings, err := loadProfileSettings(db, profile.ID)
+	if err != nil {
+		log.Printf("Error loading privacy settings: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading profile")
+		return
+	}
+
+	categories, err := loadCategories(db)
+	if err != nil {
+		log.Printf("Error loading categories: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading categories")
+		return
+	}
+
+	isOwner := user != nil && user.ID == profile.ID
+	level := trustLevel(db, profile.ID)
+	reputation, err := reputationOf(db, profile.ID)
+	if err != nil {
+		log.Printf("Error loading reputation: %v", err)
+	}
+	badges, err := loadBadges(db, profile.ID)
+	if err != nil {
+		log.Printf("Error loading badges: %v", err)
+	}
+	pageData := models.ProfilePageData{
+		User:       user,
+		Profile:    profile,
+		Settings:   settings,
+		IsOwner:    isOwner,
+		TrustLevel: level,
+		TrustName:  trustName(level),
+		Reputation: reputation,
+		Badges:     badges,
+		Categories: categories,
+	}
+
+	// Hide everything but the name when the visibility setting excludes the viewer.
+	switch settings.Visibility {
+	case visibilityMembers:
+		pageData.Restricted = user == nil
+	case visibilityPrivate:
+		pageData.Restricted = !isOwner
+	}
+
+	if !pageData.Restricted {
+		if err := loadProfileStats(db, &pageData); err != nil {
+			log.Printf("Error loading profile statistics: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading profile")
+			return
+		}
+	}
+
+	tmpl, err := template.ParseFiles("assets/template/header.html", "assets/template/profile.html")
+	if err != nil {
+		log.Printf("Error loading template: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error loading template")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	if err := tmpl.ExecuteTemplate(w, "profile", pageData); err != nil {
+		log.Printf("Rendering error: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Rendering page error")
+	}
+}
+
+// PrivacySettingsHandler saves the logged-in user's profile privacy settings.
+func PrivacySettingsHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests change state.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method not supported")
+		return
+	}
+
+	userID, err := GetUserIDFromSession(r, db)
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusUnauthorized, "User is not authorised")
+		return
+	}
+
+	visibility := r.FormValue("visibility")
+	if visibility != visibilityPublic && visibility != visibilityMembers && visibility != visibilityPrivate {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Invalid visibility")
+		return
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO profile_settings (user_id, visibility, show_bio, show_activity, show_likes, show_follows)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET
+			visibility = excluded.visibility,
+			show_bio = excluded.show_bio,
+			show_activity = excluded.show_activity,
+			show_likes = excluded.show_likes,
+			show_follows = excluded.show_follows`,
+		userID, visibility, r.FormValue("show_bio") == "on", r.FormValue("show_activity") == "on",
+		r.FormValue("show_likes") == "on", r.FormValue("show_follows") == "on")
+	if err != nil {
+		log.Printf("Error saving privacy settings: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	http.Redirect(w, r, "/user", http.StatusSeeOther)
+}
+
+// loadProfileSettings returns the user's privacy settings, or the defaults when none were saved.
+func loadProfileSettings(db *sql.DB, userID int) (models.ProfileSettings, error) {
+	settings := models.ProfileSettings{
+		UserID:       userID,
+		Visibility:   visibilityPublic,
+		ShowBio:      true,
+		ShowActivity: true,
+		ShowLikes:    true,
+		ShowFollows:  true,
+	}
+	err := db.QueryRow(`
+		SELECT visibility, show_bio, show_activity, show_likes, show_follows
+		FROM profile_settings WHERE user_id = ?`, userID).
+		Scan(&settings.Visibility, &settings.ShowBio, &settings.ShowActivity, &settings.ShowLikes, &settings.ShowFollows)
+	if err == sql.ErrNoRows {
+		return settings, nil
+	}
+	return settings, err
+}
+
+// loadProfileStats fills the counters and the recent activity of a profile that the viewer is allowed to see.
+func loadProfileStats(db *sql.DB, pageData *models.ProfilePageData) error {
+	profileID := pageData.Profile.ID
+
+	// A shadowbanned user's activity is visible only to themselves.
+	if !pageData.IsOwner && isShadowbanned(db, profileID) {
+		return nil
+	}
+	// Content hidden by reports is counted and listed only for its author and moderators.
+	viewer := 0
+	if pageData.User != nil {
+		viewer = pageData.User.ID
+	}
+
+	err := db.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM posts WHERE user_id = ? AND publish_at IS NULL AND `+unhidden("posts")+`),
+		       (SELECT COUNT(*) FROM comments WHERE user_id = ? AND `+unhidden("comments")+`)`,
+		profileID, viewer, profileID, viewer).Scan(&pageData.PostCount, &pageData.CommentCount)
+	if err != nil {
+		return err
+	}
+
+	if pageData.Settings.ShowLikes {
+		// Likes received on the user's posts and comments.
+		err = db.QueryRow(`
+			SELECT COUNT(*) FROM likes_dislikes l
+			WHERE l.reaction = 'like' AND (
+				(l.target_type = 'post' AND l.target_id IN (SELECT id FROM posts WHERE user_id = ?)) OR
+				(l.target_type = 'comment' AND l.target_id IN (SELECT id FROM comments WHERE user_id = ?)))`,
+			profileID, profileID).Scan(&pageData.LikesReceived)
+		if err != nil {
+			return err
+		}
+	}
+
+	if pageData.Settings.ShowFollows {
+		pageData.FollowerCount, pageData.FollowingCount, err = countFollows(db, profileID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if pageData.User != nil && !pageData.IsOwner {
+		pageData.IsFollowing, err = isFollowingUser(db, pageData.User.ID, profileID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !pageData.Settings.ShowActivity {
+		return nil
+	}
+
+	// Newest posts and comments, merged into one timeline.
+	rows, err := db.Query(`
+		SELECT 'post', id, id, title, body, created_at FROM posts
+		WHERE user_id = ? AND publish_at IS NULL AND `+unhidden("posts")+`
+		UNION ALL
+		SELECT 'comment', c.post_id, c.id, p.title, c.body, c.created_at
+		FROM comments c JOIN posts p ON p.id = c.post_id
+		WHERE c.user_id = ? AND `+unhidden("c")+`
+		ORDER BY 6 DESC
+		LIMIT ?`, profileID, viewer, profileID, viewer, recentActivityLimit)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var activity models.Activity
+		if err := rows.Scan(&activity.Kind, &activity.PostID, &activity.TargetID, &activity.Title,
+			&activity.Excerpt, &activity.CreatedAt); err != nil {
+			return err
+		}
+		activity.Excerpt = truncate(activity.Excerpt, 150)
+		pageData.Activity = append(pageData.Activity, activity)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"database/sql" // Provides SQL database support
+	"fmt"          // Used to build links and notifications
+	"log"          // Used for logging errors
+	"net/http"     // Provides HTTP client and server implementations
+	"strconv"      // Used to parse IDs
+)
+
+// questionPost is true for posts that are questions, either because the author marked them
+// or because they belong to a Q&A category. Queries using it alias posts as "p" and
+// categories as "c".
+const questionPost = "(p.is_question OR c.is_question)"
+
+// unansweredQuestion selects questions that have no accepted answer yet.
+const unansweredQuestion = questionPost + " AND p.accepted_comment_id IS NULL"
+
+// MarkQuestionHandler lets the author of a post, or a moderator, mark it as a question or
+// turn it back into a discussion. Posts in Q&A categories stay questions either way.
+func MarkQuestionHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests change state.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	userID, ok := requireActiveUser(w, r, db)
+	if !ok {
+		return
+	}
+
+	postID, err := strconv.Atoi(r.FormValue("post_id"))
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Incorrect ID of the post")
+		return
+	}
+
+	var authorID int
+	var publishAt sql.NullTime
+	err = db.QueryRow("SELECT user_id, publish_at FROM posts WHERE id = ?", postID).Scan(&authorID, &publishAt)
+	// A scheduled post is only known to its author and admins until it is published.
+	if err == sql.ErrNoRows || (err == nil && publishAt.Valid && authorID != userID && !isAdmin(db, userID)) {
+		RenderErrorPage(w, r, db, http.StatusNotFound, "Post not found")
+		return
+	} else if err != nil {
+		log.Printf("Error loading the post: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if authorID != userID && !isModerator(db, userID) {
+		RenderErrorPage(w, r, db, http.StatusForbidden, "Only the author can change this post")
+		return
+	}
+
+	_, err = db.Exec("UPDATE posts SET is_question = ? WHERE id = ?", r.FormValue("is_question") == "on", postID)
+	if err != nil {
+		log.Printf("Error marking the question: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error updating the post")
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/post/%d", postID), http.StatusSeeOther)
+}
+
+// AcceptAnswerHandler lets the author of a question accept one of its comments as the answer.
+// Accepting the accepted comment again withdraws the acceptance; accepting another one replaces it.
+func AcceptAnswerHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests change state.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	userID, ok := requireActiveUser(w, r, db)
+	if !ok {
+		return
+	}
+
+	commentID, err := strconv.Atoi(r.FormValue("comment_id"))
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Incorrect ID of the comment")
+		return
+	}
+
+	var postID, authorID, commenterID, acceptedID int
+	var isQuestion bool
+	var title string
+	err = db.QueryRow(`
+		SELECT p.id, p.user_id, cm.user_id, `+questionPost+`, COALESCE(p.accepted_comment_id, 0), p.title
+		FROM comments cm
+		JOIN posts p ON p.id = cm.post_id
+		JOIN categories c ON c.id = p.category_id
+		WHERE cm.id = ?`, commentID).Scan(&postID, &authorID, &commenterID, &isQuestion, &acceptedID, &title)
+	if err == sql.ErrNoRows {
+		RenderErrorPage(w, r, db, http.StatusNotFound, "Comment not found")
+		return
+	} else if err != nil {
+		log.Printf("Error loading the comment: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if authorID != userID {
+		RenderErrorPage(w, r, db, http.StatusForbidden, "Only the author of the question can accept an answer")
+		return
+	}
+	if !isQuestion {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Only questions have accepted answers")
+		return
+	}
+
+	var accepted interface{}
+	if acceptedID != commentID {
+		accepted = commentID
+	}
+	if _, err := db.Exec("UPDATE posts SET accepted_comment_id = ? WHERE id = ?", accepted, postID); err != nil {
+		log.Printf("Error accepting the answer: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error accepting the answer")
+		return
+	}
+
+	// Let the commenter know their answer helped, unless they answered their own question.
+	if accepted != nil && commenterID != userID {
+		message := fmt.Sprintf("Your answer to \"%s\" was accepted", title)
+		link := fmt.Sprintf("/post/%d#comment-%d", postID, commentID)
+		if err := CreateNotification(db, commenterID, "answer", message, link); err != nil {
+			log.Printf("Error creating answer notification: %v", err)
+		}
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/post/%d#comment-%d", postID, commentID), http.StatusSeeOther)
+}
+
+// CategoryQuestionHandler lets moderators switch a category's Q&A mode on or off.
+// In a Q&A category every post is a question.
+func CategoryQuestionHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests change state.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	if _, ok := requireModerator(w, r, db); !ok {
+		return
+	}
+
+	categoryID, err := strconv.Atoi(r.FormValue("category_id"))
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Invalid category ID")
+		return
+	}
+
+	result, err := db.Exec("UPDATE categories SET is_question = ? WHERE id = ?", r.FormValue("is_question") == "on", categoryID)
+	if err != nil {
+		log.Printf("Error updating category: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error updating category")
+		return
+	}
+	if updated, _ := result.RowsAffected(); updated == 0 {
+		RenderErrorPage(w, r, db, http.StatusNotFound, "Category not found")
+		return
+	}
+
+	http.Redirect(w, r, "/categories", http.StatusSeeOther)
+}
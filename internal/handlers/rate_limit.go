@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"database/sql" // Provides SQL database support
+	"fmt"          // Used to build bucket keys
+	"math"         // Used to round the Retry-After value up
+	"net"          // Used to extract the client IP from the remote address
+	"net/http"     // Provides HTTP client and server implementations
+	"strconv"      // Used to format the Retry-After header
+	"sync"         // Guards the limiter's bucket map
+	"time"         // Used to refill buckets
+)
+
+// RateLimit describes a token bucket: Burst requests at once, refilled at PerMinute requests per minute.
+type RateLimit struct {
+	PerMinute float64 // Sustained rate
+	Burst     int     // Bucket size
+}
+
+// RateLimits holds the per-route limits of members, indexed by trust level. Moderators and admins,
+// who are trusted with everything, get the top level's limits. Routes not listed here are not
+// limited. Edit this table to tune the limits.
+var RateLimits = map[string][]RateLimit{
+	"post":     {trustNew: {1, 2}, trustBasic: {2, 3}, trustMember: {3, 5}, trustRegular: {10, 20}},
+	"comment":  {trustNew: {3, 5}, trustBasic: {6, 10}, trustMember: {10, 20}, trustRegular: {30, 60}},
+	"reaction": {trustNew: {15, 20}, trustBasic: {30, 40}, trustMember: {60, 80}, trustRegular: {120, 200}},
+}
+
+// VisitorRateLimits holds the per-route limits of visitors without a session, per IP address.
+var VisitorRateLimits = map[string]RateLimit{
+	"post":     {1, 1},
+	"comment":  {2, 2},
+	"reaction": {5, 5},
+}
+
+// IPRateLimits holds the per-route limits shared by every request from one IP address,
+// so several accounts on one machine cannot multiply their allowance.
+var IPRateLimits = map[string]RateLimit{
+	"post":     {6, 10},
+	"comment":  {20, 40},
+	"reaction": {120, 200},
+}
+
+// bucketIdleTime is how long an unused bucket is kept; after that it would be full anyway.
+const bucketIdleTime = 30 * time.Minute
+
+// bucket is the state of one token bucket.
+type bucket struct {
+	tokens float64   // Tokens left
+	last   time.Time // Time of the last refill
+}
+
+// Limiter is an in-process set of token buckets keyed by route and user or IP.
+type Limiter struct {
+	mu        sync.Mutex         // Protects all fields below
+	buckets   map[string]*bucket // Buckets by key
+	lastSweep time.Time          // Time idle buckets were last removed
+}
+
+// NewLimiter creates a limiter with no buckets.
+func NewLimiter() *Limiter {
+	return &Limiter{buckets: make(map[string]*bucket), lastSweep: time.Now()}
+}
+
+// Limits is the limiter shared by all rate-limited routes.
+var Limits = NewLimiter()
+
+// bucketLimit names a bucket together with the limit that applies to it.
+type bucketLimit struct {
+	key   string    // Route and user or IP the bucket belongs to
+	limit RateLimit // Limit of that bucket
+}
+
+// Allow takes a token from every listed bucket, or from none of them: a request refused by one
+// bucket does not use up the others. If a bucket is empty it returns false and how long the
+// client should wait until every bucket has a token again.
+func (l *Limiter) Allow(checks ...bucketLimit) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	var wait time.Duration
+	buckets := make([]*bucket, len(checks))
+	for i, check := range checks {
+		b := l.buckets[check.key]
+		if b == nil {
+			b = &bucket{tokens: float64(check.limit.Burst), last: now}
+			l.buckets[check.key] = b
+		}
+
+		// Refill for the time since the last request, up to the bucket size.
+		perSecond := check.limit.PerMinute / 60
+		b.tokens = math.Min(float64(check.limit.Burst), b.tokens+now.Sub(b.last).Seconds()*perSecond)
+		b.last = now
+		buckets[i] = b
+
+		if b.tokens < 1 {
+			wait = max(wait, time.Duration((1-b.tokens)/perSecond*float64(time.Second)))
+		}
+	}
+	if wait > 0 {
+		return false, wait
+	}
+	for _, b := range buckets {
+		b.tokens--
+	}
+	return true, 0
+}
+
+// sweep drops buckets that have been idle long enough to be full again. The caller holds l.mu.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < bucketIdleTime {
+		return
+	}
+	for key, b := range l.buckets {
+		if now.Sub(b.last) > bucketIdleTime {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// RateLimited wraps a handler so POST requests to it are limited per user and per IP under
+// the named route's limits. Limited requests get a 429 page with a Retry-After header.
+func RateLimited(db *sql.DB, route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only writes are limited; reading pages and forms is free.
+		if r.Method != http.MethodPost {
+			next(w, r)
+			return
+		}
+
+		var checks []bucketLimit
+		if limit, ok := IPRateLimits[route]; ok {
+			checks = append(checks, bucketLimit{fmt.Sprintf("%s:ip:%s", route, clientIP(r)), limit})
+		}
+		if userID := viewerID(r, db); userID == 0 {
+			if limit, ok := VisitorRateLimits[route]; ok {
+				checks = append(checks, bucketLimit{fmt.Sprintf("%s:visitor:%s", route, clientIP(r)), limit})
+			}
+		} else if limits, ok := RateLimits[route]; ok {
+			if level := rateLevel(db, userID); level < len(limits) {
+				checks = append(checks, bucketLimit{fmt.Sprintf("%s:user:%d", route, userID), limits[level]})
+			}
+		}
+
+		if allowed, wait := Limits.Allow(checks...); !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			RenderErrorPage(w, r, db, http.StatusTooManyRequests, "Too many requests, please slow down and try again shortly")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// rateLevel returns the trust level whose limits apply to the user; moderators and admins get the top level.
+func rateLevel(db *sql.DB, userID int) int {
+	if isModerator(db, userID) {
+		return trustRegular
+	}
+	return trustLevel(db, userID)
+}
+
+// clientIP returns the IP address of the client. Forwarding headers are ignored because
+// the server is reached directly and they could be forged to dodge the limits.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
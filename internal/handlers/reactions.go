@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"database/sql"                          // Provides SQL database support
+	"encoding/json"                         // Used to answer scripts with JSON
+	"errors"                                // Used to define sentinel errors
+	"fmt"                                   // Used to build redirect paths
+	models "literary-lions/internal/models" // Imports the models package for structured data types
+	"log"                                   // Used for logging errors
+	"net/http"                              // Provides HTTP client and server implementations
+	"strconv"                               // Used to parse the target ID
+	"strings"                               // Used to inspect the Accept header
+	"time"                                  // Used to timestamp reactions
+)
+
+// ReactionTypes lists the reactions offered on posts and comments, in the order they are shown.
+// Edit this list to change the set. "like" and "dislike" must stay: reputation, trust levels,
+// badges and leaderboards are computed from them, while the other reactions are only counted.
+var ReactionTypes = []models.ReactionType{
+	{Key: "like", Emoji: "👍", Label: "Like"},
+	{Key: "dislike", Emoji: "👎", Label: "Dislike"},
+	{Key: "love", Emoji: "❤️", Label: "Love"},
+	{Key: "funny", Emoji: "😂", Label: "Funny"},
+	{Key: "thinking", Emoji: "🤔", Label: "Thought-provoking"},
+	{Key: "bookworm", Emoji: "📚", Label: "Must read"},
+}
+
+// isReaction reports whether key is one of the configured reactions.
+func isReaction(key string) bool {
+	for _, reaction := range ReactionTypes {
+		if reaction.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// errUnknownTarget is returned when a reaction names a post or comment that does not exist.
+var errUnknownTarget = errors.New("post or comment not found")
+
+// reactionResult is the outcome of toggleReaction.
+type reactionResult struct {
+	PostID int  // Post the target belongs to, or the target itself
+	Active bool // Whether the user now has the reaction; false when it was taken back
+}
+
+// toggleReaction gives the user's reaction to a post or comment. Each user keeps at most one
+// reaction per target: giving the same reaction again takes it back, and giving a different one
+// replaces it. The toggle never reads before it writes, so two quick clicks cannot both decide
+// on an insert: the delete and the upsert are keyed on the UNIQUE (user, target) row.
+func toggleReaction(db *sql.DB, userID int, targetType string, targetID int, reaction string) (reactionResult, error) {
+	var result reactionResult
+
+	// Find the post the target belongs to, which also checks that the target exists.
+	var err error
+	switch targetType {
+	case "post":
+		err = db.QueryRow("SELECT id FROM posts WHERE id = ? AND publish_at IS NULL", targetID).Scan(&result.PostID)
+	case "comment":
+		err = db.QueryRow("SELECT post_id FROM comments WHERE id = ?", targetID).Scan(&result.PostID)
+	default:
+		return result, errUnknownTarget
+	}
+	if err == sql.ErrNoRows {
+		return result, errUnknownTarget
+	} else if err != nil {
+		return result, err
+	}
+
+	// The transaction starts with a write, so it holds the write lock from its first statement
+	// and waits for a concurrent toggle instead of failing to upgrade a read lock.
+	tx, err := db.Begin()
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	// Giving the same reaction again takes it back.
+	removed, err := tx.Exec(`
+		DELETE FROM likes_dislikes
+		WHERE user_id = ? AND target_id = ? AND target_type = ? AND reaction = ?`,
+		userID, targetID, targetType, reaction)
+	if err != nil {
+		return result, err
+	}
+	if n, _ := removed.RowsAffected(); n == 0 {
+		// Otherwise the reaction is added, or replaces the user's other reaction.
+		_, err = tx.Exec(`
+			INSERT INTO likes_dislikes (user_id, target_id, target_type, reaction, created_at)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (user_id, target_id, target_type)
+			DO UPDATE SET reaction = excluded.reaction, created_at = excluded.created_at`,
+			userID, targetID, targetType, reaction, time.Now())
+		if err != nil {
+			return result, err
+		}
+		result.Active = true
+	}
+	return result, tx.Commit()
+}
+
+// ReactHandler handles "/react", the one endpoint for giving and taking back reactions to posts
+// and comments. Scripts that accept JSON get the new counts back; forms are redirected to the
+// "redirect" form value or to the reacted post or comment.
+func ReactHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests change state.
+	if r.Method != http.MethodPost {
+		reactionError(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	// Suspended and banned users cannot react.
+	userID, ok := requireActiveUser(w, r, db)
+	if !ok {
+		return
+	}
+
+	targetType := r.FormValue("target_type")
+	reaction := r.FormValue("reaction")
+	targetID, err := strconv.Atoi(r.FormValue("target_id"))
+	if err != nil || !isReaction(reaction) {
+		reactionError(w, r, db, http.StatusBadRequest, "Incorrect data")
+		return
+	}
+
+	result, err := toggleReaction(db, userID, targetType, targetID, reaction)
+	if err == errUnknownTarget {
+		reactionError(w, r, db, http.StatusNotFound, "Post or comment not found")
+		return
+	} else if err != nil {
+		log.Printf("Error updating reaction: %v", err)
+		reactionError(w, r, db, http.StatusInternalServerError, "Error updating reaction")
+		return
+	}
+
+	// Push the updated counts to everyone viewing the post and reward the author.
+	publishReactionCounts(db, targetID, targetType)
+	if result.Active {
+		awardBadgesForContent(db, targetType, targetID, badgeEventLike)
+	}
+
+	if wantsJSON(r) {
+		counts, err := CountReactions(db, targetID, targetType)
+		if err != nil {
+			log.Printf("Error counting reactions: %v", err)
+			reactionError(w, r, db, http.StatusInternalServerError, "Error counting reactions")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"target_id":   targetID,
+			"target_type": targetType,
+			"reaction":    reaction,
+			"active":      result.Active,
+			"counts":      counts,
+		})
+		return
+	}
+
+	fallback := fmt.Sprintf("/post/%d", result.PostID)
+	if targetType == "comment" {
+		fallback += fmt.Sprintf("#comment-%d", targetID)
+	}
+	http.Redirect(w, r, redirectTarget(r, fallback), http.StatusSeeOther)
+}
+
+// wantsJSON reports whether the client asked for a JSON response.
+func wantsJSON(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+// reactionError reports a failed reaction as JSON to scripts and as an error page to forms.
+func reactionError(w http.ResponseWriter, r *http.Request, db *sql.DB, status int, message string) {
+	if !wantsJSON(r) {
+		RenderErrorPage(w, r, db, status, message)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// newReactionCounts returns a zero count for every configured reaction.
+func newReactionCounts() []models.ReactionCount {
+	counts := make([]models.ReactionCount, len(ReactionTypes))
+	for i, reaction := range ReactionTypes {
+		counts[i].ReactionType = reaction
+	}
+	return counts
+}
+
+// addReaction records one reaction by username in counts; unknown reactions are ignored.
+// byViewer marks the reaction as the current user's own.
+func addReaction(counts []models.ReactionCount, key, username string, byViewer bool) {
+	for i := range counts {
+		if counts[i].Key == key {
+			counts[i].Count++
+			counts[i].Users = append(counts[i].Users, username)
+			counts[i].Active = counts[i].Active || byViewer
+			return
+		}
+	}
+}
+
+// threadReactions loads the reactions to a post and to each of its comments, with who gave them,
+// in a single query. The reactions given by viewerID are marked as active.
+func threadReactions(db *sql.DB, postID, viewerID int) ([]models.ReactionCount, map[int][]models.ReactionCount, error) {
+	rows, err := db.Query(`
+		SELECT l.target_type, l.target_id, l.reaction, l.user_id, u.username
+		FROM likes_dislikes l
+		JOIN users u ON u.id = l.user_id
+		WHERE (l.target_type = 'post' AND l.target_id = ?)
+		   OR (l.target_type = 'comment' AND l.target_id IN (SELECT id FROM comments WHERE post_id = ?))
+		ORDER BY l.created_at, l.id`, postID, postID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	postReactions := newReactionCounts()
+	commentReactions := make(map[int][]models.ReactionCount)
+	for rows.Next() {
+		var targetType, reaction, username string
+		var targetID, userID int
+		if err := rows.Scan(&targetType, &targetID, &reaction, &userID, &username); err != nil {
+			return nil, nil, err
+		}
+		byViewer := viewerID != 0 && userID == viewerID
+		if targetType == "post" {
+			addReaction(postReactions, reaction, username, byViewer)
+			continue
+		}
+		if commentReactions[targetID] == nil {
+			commentReactions[targetID] = newReactionCounts()
+		}
+		addReaction(commentReactions[targetID], reaction, username, byViewer)
+	}
+	return postReactions, commentReactions, rows.Err()
+}
+
+// CountReactions returns how often each reaction was given to a post or comment, keyed by reaction.
+func CountReactions(db *sql.DB, targetID int, targetType string) (map[string]int, error) {
+	rows, err := db.Query(`
+		SELECT reaction, COUNT(*) FROM likes_dislikes
+		WHERE target_id = ? AND target_type = ?
+		GROUP BY reaction`, targetID, targetType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for _, reaction := range ReactionTypes {
+		counts[reaction.Key] = 0
+	}
+	for rows.Next() {
+		var reaction string
+		var count int
+		if err := rows.Scan(&reaction, &count); err != nil {
+			return nil, err
+		}
+		counts[reaction] = count
+	}
+	return counts, rows.Err()
+}
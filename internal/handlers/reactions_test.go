@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	database "literary-lions/internal/db"
+)
+
+// openTestDB creates a fresh forum database, with the mock users and posts, in a temporary directory.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db := database.InitDB(filepath.Join(t.TempDir(), "forum.db"))
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// postCounters returns the like and dislike counters stored on a post.
+func postCounters(t *testing.T, db *sql.DB, postID int) (likes, dislikes int) {
+	t.Helper()
+	if err := db.QueryRow("SELECT likes, dislikes FROM posts WHERE id = ?", postID).Scan(&likes, &dislikes); err != nil {
+		t.Fatalf("reading counters: %v", err)
+	}
+	return likes, dislikes
+}
+
+// storedReaction returns the user's reaction to a post, or "" when there is none.
+func storedReaction(t *testing.T, db *sql.DB, userID, postID int) string {
+	t.Helper()
+	var reaction string
+	err := db.QueryRow(`
+		SELECT reaction FROM likes_dislikes WHERE user_id = ? AND target_type = 'post' AND target_id = ?`,
+		userID, postID).Scan(&reaction)
+	if err != nil && err != sql.ErrNoRows {
+		t.Fatalf("reading reaction: %v", err)
+	}
+	return reaction
+}
+
+func TestToggleReactionAddSwitchRemove(t *testing.T) {
+	db := openTestDB(t)
+	const userID, postID = 2, 1
+	baseLikes, baseDislikes := postCounters(t, db, postID)
+
+	steps := []struct {
+		name     string
+		reaction string
+		active   bool
+		stored   string
+		likes    int
+		dislikes int
+	}{
+		{"add", "like", true, "like", 1, 0},
+		{"switch", "dislike", true, "dislike", 0, 1},
+		{"switch to an emoji", "love", true, "love", 0, 0},
+		{"remove", "love", false, "", 0, 0},
+		{"add again", "dislike", true, "dislike", 0, 1},
+	}
+	for _, step := range steps {
+		result, err := toggleReaction(db, userID, "post", postID, step.reaction)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if result.PostID != postID || result.Active != step.active {
+			t.Errorf("%s: got post %d active %v, want post %d active %v",
+				step.name, result.PostID, result.Active, postID, step.active)
+		}
+		if got := storedReaction(t, db, userID, postID); got != step.stored {
+			t.Errorf("%s: stored reaction %q, want %q", step.name, got, step.stored)
+		}
+		likes, dislikes := postCounters(t, db, postID)
+		if likes != baseLikes+step.likes || dislikes != baseDislikes+step.dislikes {
+			t.Errorf("%s: counters %d/%d, want %d/%d",
+				step.name, likes, dislikes, baseLikes+step.likes, baseDislikes+step.dislikes)
+		}
+	}
+}
+
+func TestToggleReactionUnknownTarget(t *testing.T) {
+	db := openTestDB(t)
+	if _, err := toggleReaction(db, 1, "post", 9999, "like"); err != errUnknownTarget {
+		t.Errorf("missing post: got %v, want errUnknownTarget", err)
+	}
+	if _, err := toggleReaction(db, 1, "user", 1, "like"); err != errUnknownTarget {
+		t.Errorf("unknown target type: got %v, want errUnknownTarget", err)
+	}
+}
+
+func TestToggleReactionConcurrentClicks(t *testing.T) {
+	db := openTestDB(t)
+	const userID, postID, clicks = 3, 1, 20
+	baseLikes, _ := postCounters(t, db, postID)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, clicks)
+	for i := 0; i < clicks; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := toggleReaction(db, userID, "post", postID, "like"); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("concurrent toggle failed: %v", err)
+	}
+
+	// An even number of clicks takes the reaction back, and the counter agrees with the rows.
+	if got := storedReaction(t, db, userID, postID); got != "" {
+		t.Errorf("after %d clicks the reaction is %q, want none", clicks, got)
+	}
+	if likes, _ := postCounters(t, db, postID); likes != baseLikes {
+		t.Errorf("likes counter %d, want %d", likes, baseLikes)
+	}
+}
+
+// reactRequest posts the form to ReactHandler with the session token, if any, asking for JSON when asJSON is set.
+func reactRequest(t *testing.T, db *sql.DB, form url.Values, token string, asJSON bool) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/react", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if asJSON {
+		req.Header.Set("Accept", "application/json")
+	}
+	if token != "" {
+		req.AddCookie(&http.Cookie{Name: "session_token", Value: token})
+	}
+	rec := httptest.NewRecorder()
+	ReactHandler(rec, req, db)
+	return rec
+}
+
+// useRepoTemplates runs the rest of the test from the repository root, where handlers find
+// "assets/template" to render pages.
+func useRepoTemplates(t *testing.T) {
+	t.Helper()
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getting working directory: %v", err)
+	}
+	if err := os.Chdir(filepath.Join("..", "..")); err != nil {
+		t.Fatalf("changing to repository root: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(dir) })
+}
+
+// addSession logs the user in under the given session token.
+func addSession(t *testing.T, db *sql.DB, userID int, token string) {
+	t.Helper()
+	if _, err := db.Exec("INSERT INTO sessions (user_id, session_token) VALUES (?, ?)", userID, token); err != nil {
+		t.Fatalf("creating session: %v", err)
+	}
+}
+
+func TestReactHandlerReturnsCounts(t *testing.T) {
+	db := openTestDB(t)
+	addSession(t, db, 2, "test-token")
+	baseLikes, _ := postCounters(t, db, 1)
+
+	form := url.Values{"target_type": {"post"}, "target_id": {"1"}, "reaction": {"like"}}
+	rec := reactRequest(t, db, form, "test-token", true)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	var body struct {
+		Active bool           `json:"active"`
+		Counts map[string]int `json:"counts"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if !body.Active || body.Counts["like"] != baseLikes+1 {
+		t.Errorf("got active %v and %d likes, want true and %d", body.Active, body.Counts["like"], baseLikes+1)
+	}
+}
+
+func TestReactHandlerRedirectsForms(t *testing.T) {
+	db := openTestDB(t)
+	addSession(t, db, 3, "test-token")
+
+	// The mock data puts comment 1 on post 1.
+	tests := []struct {
+		name       string
+		targetType string
+		redirect   string
+		want       string
+	}{
+		{"post fallback", "post", "", "/post/1"},
+		{"comment fallback", "comment", "", "/post/1#comment-1"},
+		{"local redirect", "post", "/all_posts?category=2", "/all_posts?category=2"},
+		{"scheme-relative redirect", "comment", "//evil.example", "/post/1#comment-1"},
+		{"backslash redirect", "comment", `/\evil.example`, "/post/1#comment-1"},
+		{"absolute redirect", "post", "https://evil.example/", "/post/1"},
+	}
+	for _, tt := range tests {
+		form := url.Values{"target_type": {tt.targetType}, "target_id": {"1"}, "reaction": {"love"}}
+		if tt.redirect != "" {
+			form.Set("redirect", tt.redirect)
+		}
+		rec := reactRequest(t, db, form, "test-token", false)
+		if rec.Code != http.StatusSeeOther {
+			t.Errorf("%s: status %d, want 303", tt.name, rec.Code)
+			continue
+		}
+		if got := rec.Header().Get("Location"); got != tt.want {
+			t.Errorf("%s: redirected to %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReactHandlerRequiresActiveUser(t *testing.T) {
+	db := openTestDB(t)
+	useRepoTemplates(t)
+	addSession(t, db, 3, "suspended-token")
+	_, err := db.Exec(`
+		INSERT INTO user_sanctions (user_id, kind, reason, expires_at, created_by) VALUES (3, ?, 'Spam', ?, 1)`,
+		sanctionSuspension, time.Now().Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("suspending user: %v", err)
+	}
+
+	form := url.Values{"target_type": {"post"}, "target_id": {"1"}, "reaction": {"like"}}
+	if rec := reactRequest(t, db, form, "", false); rec.Code != http.StatusUnauthorized {
+		t.Errorf("visitor: status %d, want 401", rec.Code)
+	}
+	if rec := reactRequest(t, db, form, "suspended-token", false); rec.Code != http.StatusForbidden {
+		t.Errorf("suspended user: status %d, want 403", rec.Code)
+	}
+	if got := storedReaction(t, db, 3, 1); got != "" {
+		t.Errorf("suspended user's reaction was stored as %q", got)
+	}
+}
+
+func TestReactHandlerRejectsBadInput(t *testing.T) {
+	db := openTestDB(t)
+	addSession(t, db, 2, "test-token")
+
+	tests := []struct {
+		name   string
+		form   url.Values
+		status int
+	}{
+		{"unknown reaction", url.Values{"target_type": {"post"}, "target_id": {"1"}, "reaction": {"meh"}}, http.StatusBadRequest},
+		{"missing reaction", url.Values{"target_type": {"post"}, "target_id": {"1"}}, http.StatusBadRequest},
+		{"non-numeric target", url.Values{"target_type": {"post"}, "target_id": {"abc"}, "reaction": {"like"}}, http.StatusBadRequest},
+		{"unknown post", url.Values{"target_type": {"post"}, "target_id": {"9999"}, "reaction": {"like"}}, http.StatusNotFound},
+		{"unknown comment", url.Values{"target_type": {"comment"}, "target_id": {"9999"}, "reaction": {"like"}}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := reactRequest(t, db, tt.form, "test-token", true)
+		if rec.Code != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, tt.status)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%s: content type %q, want application/json", tt.name, ct)
+		}
+		var body struct {
+			Error string `json:"error"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body.Error == "" {
+			t.Errorf("%s: want an {\"error\": ...} body, got %v (%v)", tt.name, body, err)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"database/sql"                          // Provides SQL database support
+	models "literary-lions/internal/models" // Imports the models package for structured data types
+	"strings"                               // Used to build the list of post IDs
+	"time"                                  // Used to timestamp reads
+)
+
+// Read tracking remembers, per user and post, the newest comment the user has seen.
+// Comment IDs only grow, so every comment with a larger ID is unread. The user's own
+// comments never count as unread.
+
+// lastReadComment returns the ID of the newest comment the user has seen on the post and
+// whether the user has opened the post before.
+func lastReadComment(db *sql.DB, userID, postID int) (int, bool, error) {
+	var lastRead int
+	err := db.QueryRow("SELECT last_read_comment_id FROM post_reads WHERE user_id = ? AND post_id = ?",
+		userID, postID).Scan(&lastRead)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	return lastRead, err == nil, err
+}
+
+// markPostRead records that the user has seen every comment currently on the post.
+func markPostRead(db *sql.DB, userID, postID int) error {
+	_, err := db.Exec(`
+		INSERT INTO post_reads (user_id, post_id, last_read_comment_id, read_at)
+		VALUES (?, ?, (SELECT COALESCE(MAX(id), 0) FROM comments WHERE post_id = ?), ?)
+		ON CONFLICT (user_id, post_id) DO UPDATE SET
+			last_read_comment_id = excluded.last_read_comment_id,
+			read_at = excluded.read_at`,
+		userID, postID, postID, time.Now())
+	return err
+}
+
+// firstUnreadComment returns the oldest comment newer than lastRead that was not written by the user,
+// together with the number of such comments. It returns 0, 0 when everything has been read.
+func firstUnreadComment(comments []models.Comment, userID, lastRead int) (int, int) {
+	firstUnread, unread := 0, 0
+	for _, comment := range comments {
+		if comment.ID <= lastRead || comment.UserID == userID {
+			continue
+		}
+		unread++
+		if firstUnread == 0 || comment.ID < firstUnread {
+			firstUnread = comment.ID
+		}
+	}
+	return firstUnread, unread
+}
+
+// markUnread fills UnreadCount and IsNew for the given posts from the user's read history.
+// Comments by shadowbanned users and comments hidden by reports are not counted.
+func markUnread(db *sql.DB, userID int, posts []models.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+	postIDs := make([]interface{}, len(posts))
+	for i, post := range posts {
+		postIDs[i] = post.ID
+	}
+	listed := "(" + strings.TrimSuffix(strings.Repeat("?,", len(posts)), ",") + ")"
+
+	// Arguments in query order: the reader, the listed posts, then the reader for each remaining condition.
+	args := append([]interface{}{userID}, postIDs...)
+	args = append(args, userID, userID, userID)
+
+	unread := make(map[int]int)
+	rows, err := db.Query(`
+		SELECT c.post_id, COUNT(*)
+		FROM comments c
+		LEFT JOIN post_reads r ON r.post_id = c.post_id AND r.user_id = ?
+		WHERE c.post_id IN `+listed+` AND c.id > COALESCE(r.last_read_comment_id, 0) AND c.user_id != ?
+		  AND `+visibleAuthor("c.user_id")+` AND `+unhidden("c")+`
+		GROUP BY c.post_id`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var postID, count int
+		if err := rows.Scan(&postID, &count); err != nil {
+			return err
+		}
+		unread[postID] = count
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	visited := make(map[int]bool)
+	readRows, err := db.Query("SELECT post_id FROM post_reads WHERE user_id = ? AND post_id IN "+listed, args[:len(posts)+1]...)
+	if err != nil {
+		return err
+	}
+	defer readRows.Close()
+	for readRows.Next() {
+		var postID int
+		if err := readRows.Scan(&postID); err != nil {
+			return err
+		}
+		visited[postID] = true
+	}
+	if err := readRows.Err(); err != nil {
+		return err
+	}
+
+	for i := range posts {
+		posts[i].UnreadCount = unread[posts[i].ID]
+		// The user's own posts are never "new" to them.
+		posts[i].IsNew = !visited[posts[i].ID] && posts[i].UserID != userID
+	}
+	return nil
+}
+
+// unreadByCategory returns, per category ID, how many posts have comments or content the user has not read yet.
+func unreadByCategory(db *sql.DB, userID int) (map[int]int, error) {
+	rows, err := db.Query(`
+		SELECT p.category_id, COUNT(*)
+		FROM posts p
+		LEFT JOIN post_reads r ON r.post_id = p.id AND r.user_id = ?
+		WHERE p.user_id != ? AND `+publishedPost+` AND `+unhidden("p")+` AND (r.post_id IS NULL OR EXISTS (
+			SELECT 1 FROM comments c
+			WHERE c.post_id = p.id AND c.id > r.last_read_comment_id AND c.user_id != ?
+			  AND `+visibleAuthor("c.user_id")+` AND `+unhidden("c")+`))
+		GROUP BY p.category_id`, userID, userID, userID, userID, userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	unread := make(map[int]int)
+	for rows.Next() {
+		var categoryID, count int
+		if err := rows.Scan(&categoryID, &count); err != nil {
+			return nil, err
+		}
+		unread[categoryID] = count
+	}
+	return unread, rows.Err()
+}
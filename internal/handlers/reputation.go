@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"database/sql" // Provides SQL database support
+	"math"         // Used for the decay curve
+	"time"         // Used to age reactions
+)
+
+// Reputation rules.
+const (
+	reputationHalfLife = 180 * 24 * time.Hour // A reaction counts half as much after this long
+	reputationVoterCap = 5.0                  // Most reputation one voter can give or take from one author
+	ringLikesMin       = 3                    // Likes from one user to another that make a link of a possible vote ring
+)
+
+// reaction is one like or dislike together with the author of the liked content.
+type reaction struct {
+	voter, author int
+	categoryID    int // Category of the post the reaction was given in
+	isLike        bool
+	createdAt     time.Time
+}
+
+// RecomputeReputation recalculates every user's reputation from the likes and dislikes their
+// posts and comments received; other reactions do not count. Recent reactions count more than old ones, self-votes are
+// ignored, votes between members of a vote ring are ignored, and no single voter can move an
+// author's reputation by more than reputationVoterCap.
+func RecomputeReputation(db *sql.DB) error {
+	reactions, likes, err := loadReactions(db)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	scores := reputationScores(reactions, voteRings(likes), now)
+
+	// Replace the stored scores in one go so readers never see a half-updated table.
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM user_reputation"); err != nil {
+		return err
+	}
+	for userID, score := range scores {
+		_, err := tx.Exec("INSERT INTO user_reputation (user_id, score, updated_at) VALUES (?, ?, ?)",
+			userID, score, now)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// loadReactions returns every like and dislike one user gave another user's posts and comments,
+// together with how many likes each voter gave each author, keyed by {voter, author}.
+func loadReactions(db *sql.DB) ([]reaction, map[[2]int]int, error) {
+	rows, err := db.Query(`
+		SELECT l.user_id, p.user_id, p.category_id, l.reaction = 'like', l.created_at
+		FROM likes_dislikes l JOIN posts p ON l.target_type = 'post' AND p.id = l.target_id
+		WHERE l.reaction IN ('like', 'dislike')
+		UNION ALL
+		SELECT l.user_id, c.user_id, p.category_id, l.reaction = 'like', l.created_at
+		FROM likes_dislikes l JOIN comments c ON l.target_type = 'comment' AND c.id = l.target_id
+		JOIN posts p ON p.id = c.post_id
+		WHERE l.reaction IN ('like', 'dislike')`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var reactions []reaction
+	likes := make(map[[2]int]int)
+	for rows.Next() {
+		var r reaction
+		if err := rows.Scan(&r.voter, &r.author, &r.categoryID, &r.isLike, &r.createdAt); err != nil {
+			return nil, nil, err
+		}
+		if r.voter == r.author {
+			continue
+		}
+		if r.isLike {
+			likes[[2]int{r.voter, r.author}]++
+		}
+		reactions = append(reactions, r)
+	}
+	return reactions, likes, rows.Err()
+}
+
+// reputationScores applies the reputation rules to the reactions and returns the score per author:
+// each reaction decays with reputationHalfLife, votes inside a vote ring are left out, and each
+// voter's total for an author is capped at reputationVoterCap either way.
+func reputationScores(reactions []reaction, rings map[int]int, now time.Time) map[int]float64 {
+	perVoter := make(map[[2]int]float64)
+	for _, r := range reactions {
+		if ring, ok := rings[r.voter]; ok && rings[r.author] == ring {
+			continue
+		}
+		weight := math.Pow(0.5, float64(now.Sub(r.createdAt))/float64(reputationHalfLife))
+		if !r.isLike {
+			weight = -weight
+		}
+		perVoter[[2]int{r.voter, r.author}] += weight
+	}
+
+	scores := make(map[int]float64)
+	for pair, points := range perVoter {
+		scores[pair[1]] += math.Max(-reputationVoterCap, math.Min(reputationVoterCap, points))
+	}
+	return scores
+}
+
+// voteRings finds the users who like each other around a cycle: a pair liking each other, or
+// three or more accounts each liking the next, with every link at least ringLikesMin likes.
+// These are the strongly connected components of the like graph, found with Tarjan's algorithm.
+// It returns a ring number per user in a ring; users outside rings are left out.
+func voteRings(likes map[[2]int]int) map[int]int {
+	graph := make(map[int][]int)
+	for pair, count := range likes {
+		if count >= ringLikesMin {
+			graph[pair[0]] = append(graph[pair[0]], pair[1])
+		}
+	}
+
+	index := make(map[int]int)   // Visiting order of each user
+	lowLink := make(map[int]int) // Earliest user reachable from each user's subtree
+	onStack := make(map[int]bool)
+	var stack []int
+	rings := make(map[int]int)
+	ring := 0
+
+	var visit func(user int)
+	visit = func(user int) {
+		index[user] = len(index)
+		lowLink[user] = index[user]
+		stack = append(stack, user)
+		onStack[user] = true
+		for _, next := range graph[user] {
+			if _, seen := index[next]; !seen {
+				visit(next)
+				lowLink[user] = min(lowLink[user], lowLink[next])
+			} else if onStack[next] {
+				lowLink[user] = min(lowLink[user], index[next])
+			}
+		}
+		if lowLink[user] != index[user] {
+			return
+		}
+		// user is the root of a component: pop it, and keep it if it holds more than one account.
+		var members []int
+		for {
+			member := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[member] = false
+			members = append(members, member)
+			if member == user {
+				break
+			}
+		}
+		if len(members) > 1 {
+			ring++
+			for _, member := range members {
+				rings[member] = ring
+			}
+		}
+	}
+	for user := range graph {
+		if _, seen := index[user]; !seen {
+			visit(user)
+		}
+	}
+	return rings
+}
+
+// reputationOf returns the user's rounded reputation; users without reactions have 0.
+func reputationOf(db *sql.DB, userID int) (int, error) {
+	var score float64
+	err := db.QueryRow("SELECT score FROM user_reputation WHERE user_id = ?", userID).Scan(&score)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return int(math.Round(score)), err
+}
+
+// threadReputation returns the rounded reputation of the post's author and of everyone who commented on it.
+func threadReputation(db *sql.DB, postID int) (map[int]int, error) {
+	rows, err := db.Query(`
+		SELECT user_id, score FROM user_reputation
+		WHERE user_id IN (SELECT user_id FROM posts WHERE id = ? UNION SELECT user_id FROM comments WHERE post_id = ?)`,
+		postID, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reputation := make(map[int]int)
+	for rows.Next() {
+		var userID int
+		var score float64
+		if err := rows.Scan(&userID, &score); err != nil {
+			return nil, err
+		}
+		reputation[userID] = int(math.Round(score))
+	}
+	return reputation, rows.Err()
+}
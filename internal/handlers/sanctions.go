@@ -0,0 +1,334 @@
+package handlers
+
+import (
+	"database/sql"                          // Provides SQL database support
+	"errors"                                // Used to recognise sanction errors from session resolution
+	"fmt"                                   // Used to format sanction messages
+	models "literary-lions/internal/models" // Imports the models package for structured data types
+	"log"                                   // Used for logging errors
+	"net/http"                              // Provides HTTP client and server implementations
+	"strconv"                               // Used to parse suspension lengths
+	"time"                                  // Used to check and set sanction expiry
+)
+
+// Kinds of sanctions stored in "user_sanctions.kind".
+const (
+	sanctionSuspension = "suspension" // Temporarily blocks logging in and writing
+	sanctionBan        = "ban"        // Permanently blocks logging in and writing
+	sanctionShadowban  = "shadowban"  // The user's content is visible only to themselves
+)
+
+// sanctionActions maps the moderator actions recorded in the moderation log to the sanction they impose.
+var sanctionActions = map[string]string{
+	"suspend":   sanctionSuspension,
+	"ban":       sanctionBan,
+	"shadowban": sanctionShadowban,
+}
+
+// SanctionError is returned by session resolution when the account is suspended or banned.
+type SanctionError struct {
+	Kind   string    // sanctionSuspension or sanctionBan
+	Until  time.Time // End of a suspension; zero for bans
+	Reason string    // Reason given by the moderator
+}
+
+func (e *SanctionError) Error() string {
+	message := "Your account is banned"
+	if e.Kind == sanctionSuspension {
+		message = fmt.Sprintf("Your account is suspended until %s", e.Until.Local().Format("02.01.2006 15:04"))
+	}
+	if e.Reason != "" {
+		message += ": " + e.Reason
+	}
+	return message
+}
+
+// activeRestriction returns the ban or running suspension that blocks the user, or nil if there is none.
+// A ban takes precedence over suspensions; among suspensions the one ending last is reported.
+func activeRestriction(db *sql.DB, userID int) (*SanctionError, error) {
+	rows, err := db.Query(`
+		SELECT kind, expires_at, COALESCE(reason, '')
+		FROM user_sanctions
+		WHERE user_id = ? AND lifted_at IS NULL AND kind IN (?, ?)`,
+		userID, sanctionSuspension, sanctionBan)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var restriction *SanctionError
+	now := time.Now()
+	for rows.Next() {
+		var kind, reason string
+		var expiresAt sql.NullTime
+		if err := rows.Scan(&kind, &expiresAt, &reason); err != nil {
+			return nil, err
+		}
+		if kind == sanctionBan || !expiresAt.Valid {
+			return &SanctionError{Kind: sanctionBan, Reason: reason}, nil
+		}
+		if expiresAt.Time.After(now) && (restriction == nil || expiresAt.Time.After(restriction.Until)) {
+			restriction = &SanctionError{Kind: sanctionSuspension, Until: expiresAt.Time, Reason: reason}
+		}
+	}
+	return restriction, rows.Err()
+}
+
+// isShadowbanned reports whether the user's content is hidden from everyone else.
+func isShadowbanned(db *sql.DB, userID int) bool {
+	var shadowbanned bool
+	err := db.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM user_sanctions WHERE user_id = ? AND kind = ? AND lifted_at IS NULL)`,
+		userID, sanctionShadowban).Scan(&shadowbanned)
+	if err != nil {
+		log.Printf("Error checking shadowban: %v", err)
+	}
+	return shadowbanned
+}
+
+// visibleAuthor returns an SQL condition that hides content by shadowbanned users from everyone but themselves.
+// The condition takes one argument: the viewer's user ID, or 0 for anonymous visitors.
+func visibleAuthor(column string) string {
+	return fmt.Sprintf(`(%[1]s = ? OR %[1]s NOT IN (
+		SELECT user_id FROM user_sanctions WHERE kind = 'shadowban' AND lifted_at IS NULL))`, column)
+}
+
+// viewerID returns the ID of the logged-in user, or 0 for anonymous visitors.
+func viewerID(r *http.Request, db *sql.DB) int {
+	userID, err := GetUserIDFromSession(r, db)
+	if err != nil {
+		return 0
+	}
+	return userID
+}
+
+// requireActiveUser resolves the session for a write action. Suspended and banned users get
+// a 403 page explaining the sanction, visitors without a session get a 401 page.
+func requireActiveUser(w http.ResponseWriter, r *http.Request, db *sql.DB) (int, bool) {
+	userID, err := GetUserIDFromSession(r, db)
+	if err != nil {
+		var sanction *SanctionError
+		if errors.As(err, &sanction) {
+			RenderErrorPage(w, r, db, http.StatusForbidden, sanction.Error())
+		} else {
+			RenderErrorPage(w, r, db, http.StatusUnauthorized, "User is not authorised")
+		}
+		return 0, false
+	}
+	return userID, true
+}
+
+// imposeSanction records a sanction and, for suspensions and bans, ends the user's sessions
+// so it takes effect immediately. days is only used for suspensions.
+func imposeSanction(tx *sql.Tx, userID, moderatorID int, kind string, days int, reason string) (time.Time, error) {
+	var expiresAt interface{}
+	var until time.Time
+	if kind == sanctionSuspension {
+		until = time.Now().AddDate(0, 0, days)
+		expiresAt = until
+	}
+
+	_, err := tx.Exec(`
+		INSERT INTO user_sanctions (user_id, kind, reason, expires_at, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		userID, kind, reason, expiresAt, moderatorID, time.Now())
+	if err != nil {
+		return until, err
+	}
+
+	if kind != sanctionShadowban {
+		_, err = tx.Exec("DELETE FROM sessions WHERE user_id = ?", userID)
+	}
+	return until, err
+}
+
+// loadActiveSanctions returns every sanction that has not been lifted or run out, newest first.
+func loadActiveSanctions(db *sql.DB) ([]models.Sanction, error) {
+	rows, err := db.Query(`
+		SELECT s.id, s.user_id, u.username, s.kind, COALESCE(s.reason, ''), s.expires_at, m.username, s.created_at
+		FROM user_sanctions s
+		JOIN users u ON u.id = s.user_id
+		JOIN users m ON m.id = s.created_by
+		WHERE s.lifted_at IS NULL
+		ORDER BY s.created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sanctions []models.Sanction
+	now := time.Now()
+	for rows.Next() {
+		var sanction models.Sanction
+		var expiresAt sql.NullTime
+		if err := rows.Scan(&sanction.ID, &sanction.UserID, &sanction.Username, &sanction.Kind, &sanction.Reason,
+			&expiresAt, &sanction.Moderator, &sanction.CreatedAt); err != nil {
+			return nil, err
+		}
+		// Suspensions that already ran out are no longer interesting.
+		if expiresAt.Valid {
+			if !expiresAt.Time.After(now) {
+				continue
+			}
+			sanction.ExpiresAt = expiresAt.Time
+		}
+		sanctions = append(sanctions, sanction)
+	}
+	return sanctions, rows.Err()
+}
+
+// SanctionUserHandler lets a moderator "suspend", "ban" or "shadowban" a user by username,
+// independently of any report.
+func SanctionUserHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests change state.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	moderator, ok := requireModerator(w, r, db)
+	if !ok {
+		return
+	}
+
+	action := r.FormValue("action")
+	kind, known := sanctionActions[action]
+	if !known {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Unknown sanction")
+		return
+	}
+
+	var userID int
+	var role string
+	err := db.QueryRow("SELECT id, role FROM users WHERE username = ?", r.FormValue("username")).Scan(&userID, &role)
+	if err == sql.ErrNoRows {
+		moderationError(w, r, "User is not found")
+		return
+	} else if err != nil {
+		log.Printf("Error getting the user: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if userID == moderator.ID || isModeratorRole(role) {
+		moderationError(w, r, "Moderators cannot be sanctioned")
+		return
+	}
+
+	days := 0
+	if kind == sanctionSuspension {
+		if days, err = sanctionDays(r); err != nil {
+			moderationError(w, r, "Invalid suspension: "+err.Error())
+			return
+		}
+	}
+	reason := r.FormValue("reason")
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error applying sanction")
+		return
+	}
+	defer tx.Rollback()
+
+	until, err := imposeSanction(tx, userID, moderator.ID, kind, days, reason)
+	if err == nil {
+		err = logModeration(tx, moderator.ID, action, "user", userID, 0, sanctionDetails(kind, days, reason))
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("Error applying sanction: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error applying sanction")
+		return
+	}
+
+	notifySanction(db, userID, kind, until, reason)
+	http.Redirect(w, r, "/moderation", http.StatusSeeOther)
+}
+
+// LiftSanctionHandler ends a sanction before it runs out.
+func LiftSanctionHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests change state.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	moderator, ok := requireModerator(w, r, db)
+	if !ok {
+		return
+	}
+
+	var sanction models.Sanction
+	err := db.QueryRow("SELECT user_id, kind FROM user_sanctions WHERE id = ? AND lifted_at IS NULL",
+		r.FormValue("sanction_id")).Scan(&sanction.UserID, &sanction.Kind)
+	if err == sql.ErrNoRows {
+		moderationError(w, r, "The sanction was already lifted")
+		return
+	} else if err != nil {
+		log.Printf("Error loading sanction: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error lifting sanction")
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE user_sanctions SET lifted_at = ? WHERE id = ?", time.Now(), r.FormValue("sanction_id"))
+	if err == nil {
+		err = logModeration(tx, moderator.ID, "lift "+sanction.Kind, "user", sanction.UserID, 0, "")
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("Error lifting sanction: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error lifting sanction")
+		return
+	}
+
+	http.Redirect(w, r, "/moderation", http.StatusSeeOther)
+}
+
+// errSuspensionDays is returned for a suspension length that is not a whole number of days from 1 to 365.
+var errSuspensionDays = errors.New("suspensions last between 1 and 365 days")
+
+// sanctionDays reads the suspension length from the "days" form value, for both the sanction form
+// and the report queue. An empty value means defaultSuspensionDays.
+func sanctionDays(r *http.Request) (int, error) {
+	daysStr := r.FormValue("days")
+	if daysStr == "" {
+		return defaultSuspensionDays, nil
+	}
+	days, err := strconv.Atoi(daysStr)
+	if err != nil || days < 1 || days > 365 {
+		return 0, errSuspensionDays
+	}
+	return days, nil
+}
+
+// sanctionDetails builds the moderation log note for a sanction.
+func sanctionDetails(kind string, days int, reason string) string {
+	if kind == sanctionSuspension {
+		return fmt.Sprintf("%d days. %s", days, reason)
+	}
+	return reason
+}
+
+// notifySanction tells the user about a suspension or ban. Shadowbans are deliberately silent.
+func notifySanction(db *sql.DB, userID int, kind string, until time.Time, reason string) {
+	if kind == sanctionShadowban {
+		return
+	}
+	message := (&SanctionError{Kind: kind, Until: until, Reason: reason}).Error()
+	if err := CreateNotification(db, userID, "moderation", message, "/user"); err != nil {
+		log.Printf("Error creating moderation notification: %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"database/sql"                          // Provides SQL database support
+	"errors"                                // Used to report invalid publishing dates
+	"fmt"                                   // Used to build notifications
+	models "literary-lions/internal/models" // Imports the models package for structured data types
+	"log"                                   // Used for logging errors
+	"net/http"                              // Provides HTTP client and server implementations
+	"time"                                  // Used for publishing dates
+)
+
+// publishedPost is true for posts that are out, leaving out the ones scheduled for later.
+// Queries using it alias posts as "p".
+const publishedPost = "p.publish_at IS NULL"
+
+// parsePublishAt reads the optional publishing date of the new post form. It returns nil for
+// posts published right away, and an error meant for the author when the date is not in the future.
+func parsePublishAt(r *http.Request) (*time.Time, error) {
+	value := r.FormValue("publish_at")
+	if value == "" {
+		return nil, nil
+	}
+	publishAt, err := time.ParseInLocation(datetimeLocalLayout, value, time.Local)
+	if err != nil || !publishAt.After(time.Now()) {
+		return nil, errors.New("A scheduled post must be published in the future.")
+	}
+	return &publishAt, nil
+}
+
+// canPreview reports whether the viewer may see a post that is not published yet:
+// only its author and admins can.
+func canPreview(db *sql.DB, post models.Post, viewerID int) bool {
+	return post.PublishAt == nil || post.UserID == viewerID || isAdmin(db, viewerID)
+}
+
+// postVisibleTo reports whether a post exists and the viewer may see it, scheduled posts
+// being previews for their authors and admins only.
+func postVisibleTo(db *sql.DB, postID, viewerID int) (bool, error) {
+	var post models.Post
+	var publishAt sql.NullTime
+	err := db.QueryRow("SELECT user_id, publish_at FROM posts WHERE id = ?", postID).Scan(&post.UserID, &publishAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if publishAt.Valid {
+		post.PublishAt = &publishAt.Time
+	}
+	return canPreview(db, post, viewerID), nil
+}
+
+// PublishScheduledPosts publishes the scheduled posts whose time has come. Each one then gets
+// the follow-up of a post published right away, and its author is notified.
+func PublishScheduledPosts(db *sql.DB) error {
+	rows, err := db.Query("SELECT id, user_id, title, body, publish_at FROM posts WHERE publish_at IS NOT NULL")
+	if err != nil {
+		return err
+	}
+	var due []models.Post
+	now := time.Now()
+	for rows.Next() {
+		var post models.Post
+		var publishAt time.Time
+		if err := rows.Scan(&post.ID, &post.UserID, &post.Title, &post.Body, &publishAt); err != nil {
+			rows.Close()
+			return err
+		}
+		if !publishAt.After(now) {
+			post.PublishAt = &publishAt
+			due = append(due, post)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, post := range due {
+		// The post appears as new at its publishing time; the condition keeps a post from
+		// being published twice.
+		result, err := db.Exec(`
+			UPDATE posts SET publish_at = NULL, created_at = ?, last_activity_at = ?
+			WHERE id = ? AND publish_at IS NOT NULL`, *post.PublishAt, *post.PublishAt, post.ID)
+		if err != nil {
+			log.Printf("Error publishing scheduled post %d: %v", post.ID, err)
+			continue
+		}
+		if published, _ := result.RowsAffected(); published == 0 {
+			continue
+		}
+
+		if !isShadowbanned(db, post.UserID) {
+			recordMentions(db, post.UserID, "post", post.ID, post.ID, post.Body)
+		}
+		awardBadges(db, post.UserID, badgeEventPost)
+		message := fmt.Sprintf("Your scheduled post \"%s\" was published", post.Title)
+		if err := CreateNotification(db, post.UserID, "scheduled", message, fmt.Sprintf("/post/%d", post.ID)); err != nil {
+			log.Printf("Error creating scheduled post notification: %v", err)
+		}
+	}
+	return nil
+}
+
+// loadScheduledPosts returns the posts waiting for their publishing time, soonest first.
+// A userID of 0 returns the scheduled posts of every author.
+func loadScheduledPosts(db *sql.DB, userID int) ([]models.Post, error) {
+	rows, err := db.Query(`
+		SELECT p.id, p.user_id, u.username, p.title, p.publish_at
+		FROM posts p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.publish_at IS NOT NULL AND (? = 0 OR p.user_id = ?)
+		ORDER BY p.publish_at`, userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []models.Post
+	for rows.Next() {
+		var post models.Post
+		var publishAt time.Time
+		if err := rows.Scan(&post.ID, &post.UserID, &post.Author, &post.Title, &publishAt); err != nil {
+			return nil, err
+		}
+		post.PublishAt = &publishAt
+		posts = append(posts, post)
+	}
+	return posts, rows.Err()
+}
@@ -22,10 +22,13 @@ func SearchHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 
 	// Use a strings.Builder to efficiently construct the SQL query
 	var queryBuilder strings.Builder
-	// Base SQL query to search posts by title or body
-	queryBuilder.WriteString("SELECT id, title, body, created_at, category_id FROM posts WHERE (title LIKE ? OR body LIKE ?)")
-	// Add placeholders for query parameters (for search term)
-	params := []interface{}{"%" + query + "%", "%" + query + "%"}
+	// Base SQL query to search posts by title or body, hiding posts by shadowbanned users from everyone else
+	// and posts hidden by reports from everyone but their authors and moderators
+	queryBuilder.WriteString("SELECT id, title, body, created_at, category_id, likes, comment_count FROM posts WHERE (title LIKE ? OR body LIKE ?) AND publish_at IS NULL AND " +
+		visibleAuthor("user_id") + " AND " + unhidden("posts"))
+	// Add placeholders for query parameters (for search term and viewer)
+	viewer := viewerID(r, db)
+	params := []interface{}{"%" + query + "%", "%" + query + "%", viewer, viewer}
 
 	// Check if a category filter is provided
 	if category != "" {
@@ -57,7 +60,7 @@ func SearchHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 	for rows.Next() {
 		var post models.Post
 		// Scan the current row into a Post struct
-		if err := rows.Scan(&post.ID, &post.Title, &post.Body, &post.CreatedAt, &post.CategoryID); err != nil {
+		if err := rows.Scan(&post.ID, &post.Title, &post.Body, &post.CreatedAt, &post.CategoryID, &post.Likes, &post.CommentCount); err != nil {
 			// Log any scanning errors and continue processing remaining rows
 			log.Printf("Error reading post: %v", err)
 			continue
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"database/sql" // Provides SQL database support
+	"math"         // Used for log-probabilities
+	"strings"      // Used to tokenize text
+	"time"         // Used to timestamp training examples
+	"unicode"      // Used to split text into words
+)
+
+// Labels stored in "spam_examples.label".
+const (
+	labelSpam = "spam"
+	labelHam  = "ham"
+)
+
+// Naive Bayes settings.
+const (
+	spamThreshold   = 0.9 // Submissions scoring above this are held for review
+	spamMinExamples = 10  // Examples needed of each label before the classifier is used
+	maxTokenLength  = 30  // Longer "words" are usually garbage and are ignored
+)
+
+// spamTokens splits text into the set of lower-cased words it contains.
+// Each word is counted once per document.
+func spamTokens(text string) map[string]bool {
+	tokens := make(map[string]bool)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if len(word) > 1 && len(word) <= maxTokenLength {
+			tokens[word] = true
+		}
+	}
+	return tokens
+}
+
+// trainSpamExample stores a moderator-labelled text and adds its words to the model.
+func trainSpamExample(tx *sql.Tx, label, text string, moderatorID int) error {
+	_, err := tx.Exec("INSERT INTO spam_examples (label, body, created_by, created_at) VALUES (?, ?, ?, ?)",
+		label, text, moderatorID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	spam, ham := 0, 0
+	if label == labelSpam {
+		spam = 1
+	} else {
+		ham = 1
+	}
+	for token := range spamTokens(text) {
+		_, err = tx.Exec(`
+			INSERT INTO spam_tokens (token, spam_count, ham_count) VALUES (?, ?, ?)
+			ON CONFLICT (token) DO UPDATE SET
+				spam_count = spam_count + excluded.spam_count,
+				ham_count = ham_count + excluded.ham_count`,
+			token, spam, ham)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// spamScore returns the probability that text is spam. ok is false until the model has seen
+// spamMinExamples of each label.
+func spamScore(db *sql.DB, text string) (score float64, ok bool, err error) {
+	var spamDocs, hamDocs int
+	err = db.QueryRow(`
+		SELECT COALESCE(SUM(label = 'spam'), 0), COALESCE(SUM(label = 'ham'), 0) FROM spam_examples`).
+		Scan(&spamDocs, &hamDocs)
+	if err != nil || spamDocs < spamMinExamples || hamDocs < spamMinExamples {
+		return 0, false, err
+	}
+
+	// Log-odds of spam, starting from the prior and adding the evidence of every known word.
+	// Laplace smoothing keeps words seen under only one label from dominating.
+	logOdds := math.Log(float64(spamDocs) / float64(hamDocs))
+	for token := range spamTokens(text) {
+		var spamCount, hamCount int
+		err = db.QueryRow("SELECT spam_count, ham_count FROM spam_tokens WHERE token = ?", token).
+			Scan(&spamCount, &hamCount)
+		if err == sql.ErrNoRows {
+			continue
+		} else if err != nil {
+			return 0, false, err
+		}
+		pSpam := float64(spamCount+1) / float64(spamDocs+2)
+		pHam := float64(hamCount+1) / float64(hamDocs+2)
+		logOdds += math.Log(pSpam) - math.Log(pHam)
+	}
+	return 1 / (1 + math.Exp(-logOdds)), true, nil
+}
+
+// contentText returns the title and body of a post, or the body of a comment, for training.
+func contentText(tx *sql.Tx, targetType string, targetID int) (string, error) {
+	var text string
+	var err error
+	if targetType == "post" {
+		err = tx.QueryRow("SELECT title || ' ' || body FROM posts WHERE id = ?", targetID).Scan(&text)
+	} else {
+		err = tx.QueryRow("SELECT body FROM comments WHERE id = ?", targetID).Scan(&text)
+	}
+	return text, err
+}
+
+// RetrainSpamClassifier rebuilds the word counts from every stored example and returns
+// how many spam and ham examples it used.
+func RetrainSpamClassifier(db *sql.DB) (spamDocs, hamDocs int, err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT label, body FROM spam_examples")
+	if err != nil {
+		return 0, 0, err
+	}
+	counts := make(map[string][2]int)
+	for rows.Next() {
+		var label, body string
+		if err := rows.Scan(&label, &body); err != nil {
+			rows.Close()
+			return 0, 0, err
+		}
+		index := 1
+		if label == labelSpam {
+			index = 0
+			spamDocs++
+		} else {
+			hamDocs++
+		}
+		for token := range spamTokens(body) {
+			count := counts[token]
+			count[index]++
+			counts[token] = count
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM spam_tokens"); err != nil {
+		return 0, 0, err
+	}
+	for token, count := range counts {
+		_, err := tx.Exec("INSERT INTO spam_tokens (token, spam_count, ham_count) VALUES (?, ?, ?)",
+			token, count[0], count[1])
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	return spamDocs, hamDocs, tx.Commit()
+}
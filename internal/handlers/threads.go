@@ -0,0 +1,554 @@
+package handlers
+
+import (
+	"database/sql"                          // Provides SQL database support
+	"errors"                                // Used to report comments of other threads
+	"fmt"                                   // Used to build redirect paths and log details
+	models "literary-lions/internal/models" // Imports the models package for structured data types
+	"log"                                   // Used for logging errors
+	"net/http"                              // Provides HTTP client and server implementations
+	"sort"                                  // Used to order split comments by time
+	"strconv"                               // Used to parse post, comment and category IDs
+	"strings"                               // Used to trim the title of split threads
+	"time"                                  // Used to timestamp pins
+)
+
+// Pin scopes stored in "posts.pin_scope".
+const (
+	pinCategory = "category" // Pinned to the top of the post's category
+	pinSite     = "site"     // Pinned to the top of the whole forum
+)
+
+// pinnedOrder returns the ORDER BY clause that lists pinned threads first, newest pin first.
+// Site-wide pins lead every list; category pins only lead the listing of their category.
+// Queries using it alias posts as "p".
+func pinnedOrder(inCategory bool) string {
+	pinned := "COALESCE(p.pin_scope = '" + pinSite + "', 0)"
+	if inCategory {
+		pinned = "p.pin_scope IS NOT NULL"
+	}
+	// Pins that do not apply here must not reorder the rest, so pinned_at only counts for pinned rows.
+	return "ORDER BY " + pinned + " DESC, CASE WHEN " + pinned + " THEN p.pinned_at END DESC, p.created_at DESC"
+}
+
+// ThreadHandler lets moderators "pin-category", "pin-site", "unpin", "lock" or "unlock" a thread.
+// Every change is recorded in the moderation log.
+func ThreadHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests change state.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	moderator, ok := requireModerator(w, r, db)
+	if !ok {
+		return
+	}
+
+	postID, err := strconv.Atoi(r.FormValue("post_id"))
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Incorrect ID of the post")
+		return
+	}
+
+	action := r.FormValue("action")
+	var query string
+	var args []interface{}
+	switch action {
+	case "pin-category":
+		query, args = "UPDATE posts SET pin_scope = ?, pinned_at = ? WHERE id = ?", []interface{}{pinCategory, time.Now(), postID}
+	case "pin-site":
+		query, args = "UPDATE posts SET pin_scope = ?, pinned_at = ? WHERE id = ?", []interface{}{pinSite, time.Now(), postID}
+	case "unpin":
+		query, args = "UPDATE posts SET pin_scope = NULL, pinned_at = NULL WHERE id = ?", []interface{}{postID}
+	case "lock", "unlock":
+		query, args = "UPDATE posts SET locked = ? WHERE id = ?", []interface{}{action == "lock", postID}
+	default:
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Unknown action")
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error updating the thread")
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		log.Printf("Error updating the thread: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error updating the thread")
+		return
+	}
+	if updated, _ := result.RowsAffected(); updated == 0 {
+		RenderErrorPage(w, r, db, http.StatusNotFound, "Post not found")
+		return
+	}
+	err = logModeration(tx, moderator.ID, action, "post", postID, 0, r.FormValue("note"))
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("Error updating the thread: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error updating the thread")
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/post/%d", postID), http.StatusSeeOther)
+}
+
+// isThreadLocked reports whether a post is closed to new comments.
+// It returns sql.ErrNoRows when the post does not exist or is not published yet.
+func isThreadLocked(db *sql.DB, postID int) (bool, error) {
+	var locked bool
+	err := db.QueryRow("SELECT locked FROM posts WHERE id = ? AND publish_at IS NULL", postID).Scan(&locked)
+	return locked, err
+}
+
+// MoveThreadHandler lets moderators move a post to another category. The post keeps its URL.
+func MoveThreadHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests change state.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	moderator, ok := requireModerator(w, r, db)
+	if !ok {
+		return
+	}
+
+	postID, err := strconv.Atoi(r.FormValue("post_id"))
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Incorrect ID of the post")
+		return
+	}
+	categoryID, err := strconv.Atoi(r.FormValue("category_id"))
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Invalid category ID")
+		return
+	}
+
+	var categoryName string
+	err = db.QueryRow("SELECT name FROM categories WHERE id = ?", categoryID).Scan(&categoryName)
+	if err == sql.ErrNoRows {
+		RenderErrorPage(w, r, db, http.StatusNotFound, "Category not found")
+		return
+	} else if err != nil {
+		log.Printf("Error loading category: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error moving the thread")
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error moving the thread")
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE posts SET category_id = ? WHERE id = ?", categoryID, postID)
+	if err != nil {
+		log.Printf("Error moving the thread: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error moving the thread")
+		return
+	}
+	if updated, _ := result.RowsAffected(); updated == 0 {
+		RenderErrorPage(w, r, db, http.StatusNotFound, "Post not found")
+		return
+	}
+	err = logModeration(tx, moderator.ID, "move", "post", postID, 0, "to "+categoryName)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("Error moving the thread: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error moving the thread")
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/post/%d", postID), http.StatusSeeOther)
+}
+
+// MergeThreadHandler lets moderators merge a duplicate thread into another one. The duplicate
+// becomes a comment of the target, its comments join the target's in time order, and its URL
+// redirects to the target from then on.
+func MergeThreadHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests change state.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	moderator, ok := requireModerator(w, r, db)
+	if !ok {
+		return
+	}
+
+	postID, err := strconv.Atoi(r.FormValue("post_id"))
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Incorrect ID of the post")
+		return
+	}
+	targetID, err := strconv.Atoi(r.FormValue("target_id"))
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Incorrect ID of the target post")
+		return
+	}
+	if postID == targetID {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "A thread cannot be merged into itself")
+		return
+	}
+
+	// Both threads must exist, and at most one of them may have a poll.
+	var found, polls int
+	err = db.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM posts WHERE id IN (?, ?)), (SELECT COUNT(*) FROM polls WHERE post_id IN (?, ?))`,
+		postID, targetID, postID, targetID).Scan(&found, &polls)
+	if err != nil {
+		log.Printf("Error loading the threads: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error merging the threads")
+		return
+	}
+	if found < 2 {
+		RenderErrorPage(w, r, db, http.StatusNotFound, "Post not found")
+		return
+	}
+	if polls > 1 {
+		RenderErrorPage(w, r, db, http.StatusConflict, "Both threads have a poll, so they cannot be merged")
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error merging the threads")
+		return
+	}
+	defer tx.Rollback()
+
+	err = mergeThreads(tx, postID, targetID)
+	if err == nil {
+		err = logModeration(tx, moderator.ID, "merge", "post", postID, 0, fmt.Sprintf("into post %d", targetID))
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("Error merging the threads: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error merging the threads")
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/post/%d", targetID), http.StatusSeeOther)
+}
+
+// SplitThreadHandler lets moderators split selected comments off a thread into a new one.
+// The oldest selected comment opens the new thread; the old thread links to it in their place.
+func SplitThreadHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests change state.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	moderator, ok := requireModerator(w, r, db)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Error parsing the form")
+		return
+	}
+	postID, err := strconv.Atoi(r.FormValue("post_id"))
+	if err != nil {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Incorrect ID of the post")
+		return
+	}
+	var commentIDs []int
+	for _, value := range r.Form["comment_id"] {
+		commentID, err := strconv.Atoi(value)
+		if err != nil {
+			RenderErrorPage(w, r, db, http.StatusBadRequest, "Incorrect ID of the comment")
+			return
+		}
+		commentIDs = append(commentIDs, commentID)
+	}
+	if len(commentIDs) == 0 {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Select the comments to split off")
+		return
+	}
+	title := strings.TrimSpace(r.FormValue("title"))
+	if title == "" {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "The new thread needs a title")
+		return
+	}
+
+	// The new thread stays in the category of the old one unless another is chosen.
+	var categoryID int
+	err = db.QueryRow("SELECT category_id FROM posts WHERE id = ?", postID).Scan(&categoryID)
+	if err == sql.ErrNoRows {
+		RenderErrorPage(w, r, db, http.StatusNotFound, "Post not found")
+		return
+	} else if err != nil {
+		log.Printf("Error loading the post: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error splitting the thread")
+		return
+	}
+	if value := r.FormValue("category_id"); value != "" {
+		categoryID, err = strconv.Atoi(value)
+		if err != nil {
+			RenderErrorPage(w, r, db, http.StatusBadRequest, "Invalid category ID")
+			return
+		}
+		var exists int
+		if err := db.QueryRow("SELECT 1 FROM categories WHERE id = ?", categoryID).Scan(&exists); err == sql.ErrNoRows {
+			RenderErrorPage(w, r, db, http.StatusNotFound, "Category not found")
+			return
+		} else if err != nil {
+			log.Printf("Error loading category: %v", err)
+			RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error splitting the thread")
+			return
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error splitting the thread")
+		return
+	}
+	defer tx.Rollback()
+
+	newPostID, err := splitThread(tx, postID, commentIDs, title, categoryID)
+	if err == errForeignComment {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Only comments of this thread can be split off")
+		return
+	}
+	if err == nil {
+		details := fmt.Sprintf("%d comments into post %d", len(commentIDs), newPostID)
+		err = logModeration(tx, moderator.ID, "split", "post", postID, 0, details)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("Error splitting the thread: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error splitting the thread")
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/post/%d", newPostID), http.StatusSeeOther)
+}
+
+// errForeignComment is returned by splitThread when a selected comment belongs to another post.
+var errForeignComment = errors.New("comment belongs to another post")
+
+// mergeThreads turns the source post into a comment of the target, moves its comments, poll and
+// held comments over, deletes it and leaves a redirect behind. Redirects that pointed at the
+// source are passed on to the target.
+func mergeThreads(tx *sql.Tx, sourceID, targetID int) error {
+	var userID int
+	var body string
+	var createdAt time.Time
+	err := tx.QueryRow("SELECT user_id, body, created_at FROM posts WHERE id = ?", sourceID).
+		Scan(&userID, &body, &createdAt)
+	if err != nil {
+		return err
+	}
+
+	// The opening post keeps its author and date as a comment of the target.
+	result, err := tx.Exec("INSERT INTO comments (post_id, user_id, body, created_at) VALUES (?, ?, ?, ?)",
+		targetID, userID, body, createdAt)
+	if err != nil {
+		return err
+	}
+	commentID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	if err := retarget(tx, "post", sourceID, "comment", int(commentID), targetID); err != nil {
+		return err
+	}
+
+	statements := []string{
+		"UPDATE mentions SET post_id = ? WHERE post_id = ?",
+		"UPDATE comments SET post_id = ? WHERE post_id = ?",
+		"UPDATE held_submissions SET post_id = ? WHERE post_id = ? AND target_type = 'comment'",
+		"UPDATE polls SET post_id = ? WHERE post_id = ?",
+		"UPDATE post_redirects SET to_post_id = ? WHERE to_post_id = ?",
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, targetID, sourceID); err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec("INSERT INTO post_redirects (from_post_id, to_post_id, created_at) VALUES (?, ?, ?)",
+		sourceID, targetID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// Only the empty post, its read markers and anything else left on it remain to be deleted.
+	if err := deletePost(tx, sourceID); err != nil {
+		return err
+	}
+	return refreshActivity(tx, targetID)
+}
+
+// splitThread moves the given comments of a post into a new thread and returns its ID.
+// The oldest comment becomes the opening post, keeping its author and date; every moved
+// comment leaves a redirect so its old link leads to the new thread.
+func splitThread(tx *sql.Tx, postID int, commentIDs []int, title string, categoryID int) (int, error) {
+	type splitComment struct {
+		id, userID int
+		body       string
+		createdAt  time.Time
+	}
+	var comments []splitComment
+	for _, commentID := range commentIDs {
+		c := splitComment{id: commentID}
+		var commentPostID int
+		err := tx.QueryRow("SELECT post_id, user_id, body, created_at FROM comments WHERE id = ?", commentID).
+			Scan(&commentPostID, &c.userID, &c.body, &c.createdAt)
+		if err == sql.ErrNoRows || (err == nil && commentPostID != postID) {
+			return 0, errForeignComment
+		} else if err != nil {
+			return 0, err
+		}
+		comments = append(comments, c)
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		if comments[i].createdAt.Equal(comments[j].createdAt) {
+			return comments[i].id < comments[j].id
+		}
+		return comments[i].createdAt.Before(comments[j].createdAt)
+	})
+
+	first := comments[0]
+	result, err := tx.Exec("INSERT INTO posts (user_id, title, body, category_id, created_at) VALUES (?, ?, ?, ?, ?)",
+		first.userID, title, first.body, categoryID, first.createdAt)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	newPostID := int(id)
+
+	if err := retarget(tx, "comment", first.id, "post", newPostID, newPostID); err != nil {
+		return 0, err
+	}
+	if err := deleteComment(tx, first.id); err != nil {
+		return 0, err
+	}
+	// The other comments keep their IDs, but no longer answer the old thread's question.
+	for _, c := range comments[1:] {
+		if _, err := tx.Exec("UPDATE posts SET accepted_comment_id = NULL WHERE accepted_comment_id = ?", c.id); err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec("UPDATE comments SET post_id = ? WHERE id = ?", newPostID, c.id); err != nil {
+			return 0, err
+		}
+		_, err := tx.Exec("UPDATE mentions SET post_id = ? WHERE target_type = 'comment' AND target_id = ?", newPostID, c.id)
+		if err != nil {
+			return 0, err
+		}
+	}
+	for _, c := range comments {
+		_, err := tx.Exec("INSERT INTO post_redirects (from_post_id, comment_id, to_post_id, created_at) VALUES (?, ?, ?, ?)",
+			postID, c.id, newPostID, time.Now())
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := refreshActivity(tx, postID); err != nil {
+		return 0, err
+	}
+	return newPostID, refreshActivity(tx, newPostID)
+}
+
+// retarget hands the reactions, mentions, bookmarks and reports of a post or comment over to the
+// post or comment that replaces it; postID is the post the replacement belongs to. Reactions are
+// inserted anew so that the counter triggers count them on the replacement.
+func retarget(tx *sql.Tx, fromType string, fromID int, toType string, toID, postID int) error {
+	_, err := tx.Exec(`
+		INSERT INTO likes_dislikes (user_id, target_id, target_type, is_like, reaction, created_at)
+		SELECT user_id, ?, ?, is_like, reaction, created_at
+		FROM likes_dislikes WHERE target_type = ? AND target_id = ?`,
+		toID, toType, fromType, fromID)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM likes_dislikes WHERE target_type = ? AND target_id = ?", fromType, fromID); err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE mentions SET target_type = ?, target_id = ?, post_id = ? WHERE target_type = ? AND target_id = ?",
+		toType, toID, postID, fromType, fromID)
+	if err != nil {
+		return err
+	}
+	for _, table := range []string{"bookmarks", "reports"} {
+		_, err := tx.Exec("UPDATE "+table+" SET target_type = ?, target_id = ? WHERE target_type = ? AND target_id = ?",
+			toType, toID, fromType, fromID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refreshActivity recomputes when a post last saw activity after comments were moved in or out.
+func refreshActivity(tx *sql.Tx, postID int) error {
+	_, err := tx.Exec(`
+		UPDATE posts SET last_activity_at = COALESCE((SELECT MAX(created_at) FROM comments WHERE post_id = posts.id), created_at)
+		WHERE id = ?`, postID)
+	return err
+}
+
+// mergedInto reports the thread a merged post now lives in.
+func mergedInto(db *sql.DB, postID int) (int, bool) {
+	var targetID int
+	err := db.QueryRow(`
+		SELECT to_post_id FROM post_redirects WHERE from_post_id = ? AND comment_id IS NULL
+		ORDER BY id DESC LIMIT 1`, postID).Scan(&targetID)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error loading post redirect: %v", err)
+	}
+	return targetID, err == nil
+}
+
+// loadMovedComments returns the comments split off a post, grouped by the thread they moved to.
+func loadMovedComments(db *sql.DB, postID int) ([]models.MovedComments, error) {
+	rows, err := db.Query(`
+		SELECT r.comment_id, r.to_post_id, p.title
+		FROM post_redirects r
+		JOIN posts p ON p.id = r.to_post_id
+		WHERE r.from_post_id = ? AND r.comment_id IS NOT NULL
+		ORDER BY r.id`, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var moved []models.MovedComments
+	for rows.Next() {
+		var commentID, toPostID int
+		var title string
+		if err := rows.Scan(&commentID, &toPostID, &title); err != nil {
+			return nil, err
+		}
+		if n := len(moved); n > 0 && moved[n-1].PostID == toPostID {
+			moved[n-1].CommentIDs = append(moved[n-1].CommentIDs, commentID)
+			continue
+		}
+		moved = append(moved, models.MovedComments{PostID: toPostID, Title: title, CommentIDs: []int{commentID}})
+	}
+	return moved, rows.Err()
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"database/sql" // Provides SQL database support
+	"fmt"          // Used to format notifications
+	"log"          // Used for logging errors
+	"net/http"     // Provides HTTP client and server implementations
+	"strings"      // Used to trim form values
+	"time"         // Used for account age
+)
+
+// Trust levels stored in "user_trust.level". Each level unlocks more of the forum.
+const (
+	trustNew     = iota // Fresh account
+	trustBasic          // Has looked around; may post links without review and upload a profile image
+	trustMember         // Takes part regularly
+	trustRegular        // Long-time contributor; may create categories
+)
+
+// trustNames are the labels shown on profiles, indexed by level.
+var trustNames = []string{"New", "Basic", "Member", "Regular"}
+
+// Levels needed for gated features.
+const (
+	trustToPostLinks      = trustBasic
+	trustToUploadImages   = trustBasic
+	trustToCreateCategory = trustRegular
+)
+
+// reportHideWeight is the combined weight of open reports that hides a post or comment until a
+// moderator reviews them: five new members, or two members of level 2.
+const reportHideWeight = 5
+
+// trustRequirement is what a user needs to reach a level.
+type trustRequirement struct {
+	Days    int // Account age in days
+	Read    int // Posts read
+	Written int // Posts and comments written
+	Likes   int // Likes received from other users
+}
+
+// trustRequirements are indexed by level; level 0 has no requirements.
+var trustRequirements = []trustRequirement{
+	trustNew:     {},
+	trustBasic:   {Days: 1, Read: 5},
+	trustMember:  {Days: 15, Read: 30, Written: 10, Likes: 5},
+	trustRegular: {Days: 60, Read: 100, Written: 50, Likes: 30},
+}
+
+// trustLevel returns the stored trust level of the user, or trustNew if none was computed yet.
+func trustLevel(db *sql.DB, userID int) int {
+	var level int
+	err := db.QueryRow("SELECT level FROM user_trust WHERE user_id = ?", userID).Scan(&level)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error loading trust level: %v", err)
+	}
+	return level
+}
+
+// hasTrust reports whether the user may use a feature gated at the given level.
+// Moderators and admins are trusted with everything.
+func hasTrust(db *sql.DB, userID, level int) bool {
+	if trustLevel(db, userID) >= level {
+		return true
+	}
+	var role string
+	err := db.QueryRow("SELECT role FROM users WHERE id = ?", userID).Scan(&role)
+	return err == nil && isModeratorRole(role)
+}
+
+// flagWeight returns the weight of a report filed by the user, which grows with their trust level.
+func flagWeight(db *sql.DB, userID int) int {
+	return 1 + trustLevel(db, userID)
+}
+
+// trustName returns the label of a trust level.
+func trustName(level int) string {
+	if level < 0 || level >= len(trustNames) {
+		return trustNames[trustNew]
+	}
+	return trustNames[level]
+}
+
+// RecomputeTrustLevels works out every user's level from their activity, stores it and
+// congratulates users who went up.
+func RecomputeTrustLevels(db *sql.DB) error {
+	rows, err := db.Query(`
+		SELECT u.id, u.created_at,
+		       (SELECT COUNT(*) FROM post_reads WHERE user_id = u.id),
+		       (SELECT COUNT(*) FROM posts WHERE user_id = u.id AND publish_at IS NULL) + (SELECT COUNT(*) FROM comments WHERE user_id = u.id),
+		       (SELECT COUNT(*) FROM likes_dislikes l
+		        WHERE l.reaction = 'like' AND l.user_id != u.id AND (
+		            (l.target_type = 'post' AND l.target_id IN (SELECT id FROM posts WHERE user_id = u.id)) OR
+		            (l.target_type = 'comment' AND l.target_id IN (SELECT id FROM comments WHERE user_id = u.id)))),
+		       COALESCE(t.level, -1)
+		FROM users u
+		LEFT JOIN user_trust t ON t.user_id = u.id`)
+	if err != nil {
+		return err
+	}
+
+	type change struct{ userID, level, previous int }
+	var changes []change
+	now := time.Now()
+	for rows.Next() {
+		var userID, read, written, likes, previous int
+		var createdAt time.Time
+		if err := rows.Scan(&userID, &createdAt, &read, &written, &likes, &previous); err != nil {
+			rows.Close()
+			return err
+		}
+		days := int(now.Sub(createdAt).Hours() / 24)
+
+		level := trustNew
+		for candidate := trustBasic; candidate < len(trustRequirements); candidate++ {
+			req := trustRequirements[candidate]
+			if days < req.Days || read < req.Read || written < req.Written || likes < req.Likes {
+				break
+			}
+			level = candidate
+		}
+		if level != previous {
+			changes = append(changes, change{userID, level, previous})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, c := range changes {
+		_, err := db.Exec(`
+			INSERT INTO user_trust (user_id, level, updated_at) VALUES (?, ?, ?)
+			ON CONFLICT (user_id) DO UPDATE SET level = excluded.level, updated_at = excluded.updated_at`,
+			c.userID, c.level, now)
+		if err != nil {
+			return err
+		}
+		// Users who have never been computed start silently at their level.
+		if c.previous >= 0 && c.level > c.previous {
+			message := fmt.Sprintf("You reached trust level %d (%s)", c.level, trustName(c.level))
+			if err := CreateNotification(db, c.userID, "trust", message, "/user"); err != nil {
+				log.Printf("Error creating trust notification: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// CreateCategoryHandler lets users with enough trust add a new category.
+func CreateCategoryHandler(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	// Only POST requests change state.
+	if r.Method != http.MethodPost {
+		RenderErrorPage(w, r, db, http.StatusMethodNotAllowed, "Method is not supported")
+		return
+	}
+
+	userID, ok := requireActiveUser(w, r, db)
+	if !ok {
+		return
+	}
+	if !hasTrust(db, userID, trustToCreateCategory) {
+		RenderErrorPage(w, r, db, http.StatusForbidden,
+			fmt.Sprintf("Creating categories requires trust level %d (%s)", trustToCreateCategory, trustName(trustToCreateCategory)))
+		return
+	}
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	description := strings.TrimSpace(r.FormValue("description"))
+	if name == "" || description == "" {
+		RenderErrorPage(w, r, db, http.StatusBadRequest, "Name and description are required")
+		return
+	}
+
+	var exists bool
+	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM categories WHERE name = ?)", name).Scan(&exists)
+	if err != nil {
+		log.Printf("Error checking category: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if exists {
+		RenderErrorPage(w, r, db, http.StatusConflict, "A category with this name already exists")
+		return
+	}
+
+	_, err = db.Exec("INSERT INTO categories (name, description, is_question, created_at) VALUES (?, ?, ?, ?)",
+		name, description, r.FormValue("is_question") == "on", time.Now())
+	if err != nil {
+		log.Printf("Error creating category: %v", err)
+		RenderErrorPage(w, r, db, http.StatusInternalServerError, "Error creating category")
+		return
+	}
+
+	http.Redirect(w, r, "/categories", http.StatusSeeOther)
+}
@@ -18,6 +18,7 @@ import (
 )
 
 // GetUserIDFromSession retrieves the user ID associated with the session token from the database.
+// Suspended and banned users are rejected with a *SanctionError.
 func GetUserIDFromSession(r *http.Request, db *sql.DB) (int, error) {
 	// Get the session token from the cookie named "session_token".
 	cookie, err := r.Cookie("session_token")
@@ -34,6 +35,15 @@ func GetUserIDFromSession(r *http.Request, db *sql.DB) (int, error) {
 		return 0, err
 	}
 
+	// Refuse the session while the account is suspended or banned.
+	restriction, err := activeRestriction(db, userID)
+	if err != nil {
+		return 0, err
+	}
+	if restriction != nil {
+		return 0, restriction
+	}
+
 	// Return the user ID and no error if the session is valid.
 	return userID, nil
 }
@@ -57,7 +67,7 @@ func HandleUserPage(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 		if err == nil {
 			// Initialize the User struct and fetch user details from the database.
 			user = &models.User{}
-			err = db.QueryRow("SELECT id, username, email, COALESCE(bio, ''), COALESCE(profile_image, '') FROM users WHERE id = ?", userID).Scan(&user.ID, &user.Username, &user.Email, &user.Bio, &user.ProfImage)
+			err = db.QueryRow("SELECT id, username, email, role, COALESCE(bio, ''), COALESCE(profile_image, '') FROM users WHERE id = ?", userID).Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.Bio, &user.ProfImage)
 			if err != nil {
 				// Log an error if user details cannot be retrieved.
 				log.Printf("Error getting the user: %v", err)
@@ -95,10 +105,47 @@ func HandleUserPage(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 		return
 	}
 
+	// Count followers and followed users for the profile.
+	var followers, following int
+	if user != nil {
+		followers, following, err = countFollows(db, user.ID)
+		if err != nil {
+			log.Printf("Error counting follows: %v", err)
+		}
+	}
+
+	// Load the privacy settings for the settings form.
+	var privacy models.ProfileSettings
+	if user != nil {
+		privacy, err = loadProfileSettings(db, user.ID)
+		if err != nil {
+			log.Printf("Error loading privacy settings: %v", err)
+		}
+	}
+
+	// Load the user's unpublished drafts and scheduled posts.
+	var drafts []models.Draft
+	var scheduled []models.Post
+	if user != nil {
+		drafts, err = loadDrafts(db, user.ID)
+		if err != nil {
+			log.Printf("Error loading drafts: %v", err)
+		}
+		scheduled, err = loadScheduledPosts(db, user.ID)
+		if err != nil {
+			log.Printf("Error loading scheduled posts: %v", err)
+		}
+	}
+
 	// Create a struct to pass user and category data to the template.
 	pageData := models.UserPageData{
-		User:       user,       // The user data (can be nil if not logged in).
-		Categories: categories, // The list of categories.
+		User:           user,       // The user data (can be nil if not logged in).
+		Categories:     categories, // The list of categories.
+		FollowerCount:  followers,  // Number of followers.
+		FollowingCount: following,  // Number of followed users.
+		Privacy:        privacy,    // Privacy settings of the public profile.
+		Drafts:         drafts,     // Unpublished drafts of the user.
+		Scheduled:      scheduled,  // Posts of the user waiting to be published.
 	}
 
 	// Parse the templates for rendering the user page.
@@ -279,6 +326,13 @@ func HandleUploadProfileImage(w http.ResponseWriter, r *http.Request, db *sql.DB
 		return
 	}
 
+	// New accounts cannot upload images until they reach the required trust level.
+	if !hasTrust(db, userID, trustToUploadImages) {
+		RenderErrorPage(w, r, db, http.StatusForbidden,
+			fmt.Sprintf("Uploading images requires trust level %d (%s)", trustToUploadImages, trustName(trustToUploadImages)))
+		return
+	}
+
 	var oldFilePath string
 	// Query the database to get the current profile image file path.
 	err = db.QueryRow("SELECT profile_image FROM users WHERE id = ?", userID).Scan(&oldFilePath)
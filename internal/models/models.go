@@ -23,19 +23,32 @@ type Category struct {
 	ID          int            `db:"id"`          // Unique identifier for the category, corresponds to the "id" column in the database
 	Name        string         `db:"name"`        // Name of the category, stored in "name" column
 	Description sql.NullString `db:"description"` // Optional description of the category, supports null values, mapped to "description"
+	IsQuestion  bool           `db:"is_question"` // Whether every post in the category is a question, stored in "is_question"
 	CreatedAt   time.Time      `db:"created_at"`  // Timestamp of category creation, stored in "created_at" column
 }
 
 // Post represents a forum post
 type Post struct {
-	ID           int       `db:"id"`          // Unique identifier for the post, corresponds to the "id" column
-	UserID       int       `db:"user_id"`     // ID of the user who created the post, mapped to "user_id" column
-	Title        string    `db:"title"`       // Title of the post, stored in "title" column
-	Body         string    `db:"body"`        // Content of the post, stored in "body" column
-	CategoryID   int       `db:"category_id"` // ID of the category the post belongs to, mapped to "category_id"
-	CreatedAt    time.Time `db:"created_at"`  // Timestamp of post creation, stored in "created_at" column
-	Author       string    // Author's username, not mapped to the database
-	CategoryName string    // Name of the post's category, not mapped to the database
+	ID           int        `db:"id"`                  // Unique identifier for the post, corresponds to the "id" column
+	UserID       int        `db:"user_id"`             // ID of the user who created the post, mapped to "user_id" column
+	Title        string     `db:"title"`               // Title of the post, stored in "title" column
+	Body         string     `db:"body"`                // Content of the post, stored in "body" column
+	CategoryID   int        `db:"category_id"`         // ID of the category the post belongs to, mapped to "category_id"
+	CreatedAt    time.Time  `db:"created_at"`          // Timestamp of post creation, stored in "created_at" column
+	Likes        int        `db:"likes"`               // Number of "like" reactions, stored in "likes" column
+	Dislikes     int        `db:"dislikes"`            // Number of "dislike" reactions, stored in "dislikes" column
+	CommentCount int        `db:"comment_count"`       // Number of comments, stored in "comment_count" column
+	LastActivity time.Time  `db:"last_activity_at"`    // Timestamp of the post or its newest comment, stored in "last_activity_at"
+	IsQuestion   bool       `db:"is_question"`         // Whether the post is a question, stored in "is_question" (or set by its category)
+	AcceptedID   int        `db:"accepted_comment_id"` // ID of the accepted answer, 0 if none, stored in "accepted_comment_id"
+	PinScope     string     `db:"pin_scope"`           // "category" or "site" when pinned, empty otherwise, stored in "pin_scope"
+	Locked       bool       `db:"locked"`              // Whether the thread is closed to new comments, stored in "locked"
+	PublishAt    *time.Time `db:"publish_at"`          // When a scheduled post goes out, nil once published, stored in "publish_at"
+	Hidden       bool       `db:"hidden"`              // Whether reports hid the post until a moderator reviews them, stored in "hidden"
+	Author       string     // Author's username, not mapped to the database
+	CategoryName string     // Name of the post's category, not mapped to the database
+	UnreadCount  int        // Comments the current user has not read yet, not mapped to the database
+	IsNew        bool       // Whether the current user has never opened the post, not mapped to the database
 }
 
 // Comment represents a comment on a forum post
@@ -45,25 +58,84 @@ type Comment struct {
 	UserID    int       `db:"user_id"`    // ID of the user who made the comment, stored in "user_id"
 	Body      string    `db:"body"`       // Content of the comment, stored in "body" column
 	CreatedAt time.Time `db:"created_at"` // Timestamp of comment creation, mapped to "created_at"
+	Hidden    bool      `db:"hidden"`     // Whether reports hid the comment until a moderator reviews them, stored in "hidden"
 	Title     string    // Title of the post being commented on, not stored in the database
 	Username  string    // Username of the commenter, not mapped to the database
 }
 
-// LikeDislike represents a user's reaction (like or dislike) to a post or comment
+// MovedComments represents comments a moderator split off a thread into a new one
+type MovedComments struct {
+	PostID     int    // ID of the post the comments were moved to
+	Title      string // Title of that post
+	CommentIDs []int  // IDs of the moved comments, so their old anchors keep working
+}
+
+// Draft represents a post its author saved without publishing it yet
+type Draft struct {
+	ID         int       `db:"id"`          // Unique identifier for the draft, corresponds to the "id" column
+	UserID     int       `db:"user_id"`     // ID of the author, stored in "user_id" column
+	Title      string    `db:"title"`       // Title written so far, stored in "title" column
+	Body       string    `db:"body"`        // Text written so far, stored in "body" column
+	CategoryID int       `db:"category_id"` // Chosen category, 0 if none, stored in "category_id"
+	UpdatedAt  time.Time `db:"updated_at"`  // Timestamp of the latest save, mapped to "updated_at"
+}
+
+// Poll represents a poll attached to a post
+type Poll struct {
+	ID          int          `db:"id"`        // Unique identifier for the poll, corresponds to the "id" column
+	PostID      int          `db:"post_id"`   // ID of the post the poll is attached to, stored in "post_id"
+	Question    string       `db:"question"`  // Question put to the voters, stored in "question" column
+	Multiple    bool         `db:"multiple"`  // Whether voters may choose several options, stored in "multiple"
+	Results     string       `db:"results"`   // Who sees the results ("always", "voted" or "closed"), stored in "results"
+	ClosesAt    *time.Time   `db:"closes_at"` // End of voting, nil if the poll stays open, stored in "closes_at"
+	Options     []PollOption // Options in their display order, not mapped to the database
+	Voters      int          // Number of users who voted, not mapped to the database
+	Closed      bool         // Whether voting has ended, not mapped to the database
+	HasVoted    bool         // Whether the current user voted, not mapped to the database
+	ShowResults bool         // Whether the current user may see the results, not mapped to the database
+}
+
+// PollOption represents one answer of a poll
+type PollOption struct {
+	ID      int    `db:"id"`      // Unique identifier for the option, corresponds to the "id" column
+	PollID  int    `db:"poll_id"` // ID of the poll, stored in "poll_id" column
+	Label   string `db:"label"`   // Text of the option, stored in "label" column
+	Votes   int    // Number of votes for the option, not mapped to the database
+	Percent int    // Share of the voters who chose the option, not mapped to the database
+	Chosen  bool   // Whether the current user chose the option, not mapped to the database
+}
+
+// LikeDislike represents a user's reaction (like, dislike or emoji) to a post or comment
 type LikeDislike struct {
 	ID         int       `db:"id"`          // Unique identifier for the like/dislike, corresponds to "id"
 	UserID     int       `db:"user_id"`     // ID of the user who reacted, stored in "user_id"
 	TargetID   int       `db:"target_id"`   // ID of the target (post or comment), mapped to "target_id"
 	TargetType string    `db:"target_type"` // Type of the target (e.g., "post" or "comment"), stored in "target_type"
-	IsLike     bool      `db:"is_like"`     // True for like, false for dislike, stored in "is_like"
+	Reaction   string    `db:"reaction"`    // Key of the reaction, e.g. "like" or "love", stored in "reaction"
 	CreatedAt  time.Time `db:"created_at"`  // Timestamp of the reaction, stored in "created_at"
 }
 
+// ReactionType is one of the reactions members can give to posts and comments
+type ReactionType struct {
+	Key   string // Stored in "likes_dislikes.reaction", e.g. "love"
+	Emoji string // Shown on the reaction buttons
+	Label string // Name of the reaction, e.g. "Love"
+}
+
+// ReactionCount holds how often a reaction was given to a post or comment, and by whom
+type ReactionCount struct {
+	ReactionType
+	Count  int      // Number of members who gave the reaction
+	Users  []string // Usernames of the members who gave the reaction, oldest first
+	Active bool     // Whether the current user gave the reaction
+}
+
 // IndexPageData contains data for rendering the index page
 type IndexPageData struct {
 	Posts      []Post     // List of posts to display on the page
 	User       *User      // Current logged-in user (if any)
 	Categories []Category // List of categories for navigation
+	IsFeed     bool       // True when Posts come from the user's personalized feed
 }
 
 // PostsPageData contains data for rendering a page displaying multiple posts
@@ -71,26 +143,40 @@ type PostsPageData struct {
 	Posts      []Post     // List of posts
 	User       *User      // Current logged-in user
 	Categories []Category // List of categories
+	Filter     string     // Active filter, e.g. "unanswered", or empty for all posts
+	CategoryID int        // Category the posts are limited to, or 0
 }
 
 // PostPageData contains data for rendering a single post page
 type PostPageData struct {
-	Post          Post                     // Post to display
-	User          *User                    // Current logged-in user
-	Comments      []Comment                // List of comments on the post
-	PostLikes     int                      // Count of likes for the post
-	PostDislikes  int                      // Count of dislikes for the post
-	CommentCounts map[int]LikeDislikeCount // Mapping of comment IDs to their like/dislike counts
-	Categories    []Category               // List of categories
-	Author        string                   // Author of the post
-	Category      string                   // Category name of the post
-	ErrorMessage  string                   // Error message to display (if any)
+	Post             Post                    // Post to display
+	User             *User                   // Current logged-in user
+	Comments         []Comment               // List of comments on the post
+	Reactions        []ReactionCount         // Reactions to the post, in the configured order
+	CommentReactions map[int][]ReactionCount // Mapping of comment IDs to their reactions
+	Categories       []Category              // List of categories
+	Author           string                  // Author of the post
+	Category         string                  // Category name of the post
+	ErrorMessage     string                  // Error message to display (if any)
+	Notice           string                  // Informational message to display (if any)
+	FollowsAuthor    bool                    // Whether the current user follows the post's author
+	BookmarkLists    []BookmarkList          // Reading lists of the current user, for the bookmark form
+	LastReadID       int                     // ID of the newest comment the user had read before this visit
+	FirstUnreadID    int                     // ID of the oldest unread comment, or 0 if everything was read
+	UnreadCount      int                     // Number of comments that are new since the user's last visit
+	Reputation       map[int]int             // Reputation of the post's author and commenters, by user ID
+	Poll             *Poll                   // Poll attached to the post, or nil
+	QuestionCategory bool                    // Whether the post is a question because of its category
+	AcceptedAnswer   *Comment                // Comment accepted as the answer, pinned under the post, or nil
+	IsModerator      bool                    // Whether the current user is a moderator or an admin
+	Moved            []MovedComments         // Comments split off the post into other threads
 }
 
-// LikeDislikeCount holds like and dislike counts for a target
-type LikeDislikeCount struct {
-	Likes    int // Count of likes
-	Dislikes int // Count of dislikes
+// CommentFragmentData contains data for rendering a new comment streamed to open post pages
+type CommentFragmentData struct {
+	Comment    Comment         // Comment to display
+	Reputation int             // Reputation of the comment's author
+	Reactions  []ReactionCount // Reactions to the comment, in the configured order
 }
 
 // NewPostPageData contains data for rendering the new post creation page
@@ -98,6 +184,8 @@ type NewPostPageData struct {
 	User         *User      // Current logged-in user
 	Categories   []Category // List of categories for selection
 	ErrorMessage string     // Error message to display (if any)
+	Notice       string     // Informational message to display (if any)
+	Draft        *Draft     // Draft being continued, or nil for a fresh post
 }
 
 // LoginPageData contains data for rendering the login page
@@ -125,8 +213,13 @@ type RegisterPageData struct {
 
 // UserPageData contains data for rendering a user's profile page
 type UserPageData struct {
-	User       *User      // User data for the profile
-	Categories []Category // List of categories
+	User           *User           // User data for the profile
+	Categories     []Category      // List of categories
+	FollowerCount  int             // Number of users following the user
+	FollowingCount int             // Number of users the user follows
+	Privacy        ProfileSettings // Privacy settings of the public profile
+	Drafts         []Draft         // Unpublished posts of the user, most recently saved first
+	Scheduled      []Post          // Posts of the user waiting for their publishing time, soonest first
 }
 
 // UserCommentsPageData contains data for rendering a user's comments page
@@ -143,3 +236,301 @@ type SearchResultsPageData struct {
 	User       *User      // Current logged-in user
 	Categories []Category // List of categories
 }
+
+// Notification represents a message delivered to a user about activity that concerns them
+type Notification struct {
+	ID        int       `db:"id"`         // Unique identifier for the notification, corresponds to the "id" column
+	UserID    int       `db:"user_id"`    // ID of the user receiving the notification, mapped to "user_id"
+	Kind      string    `db:"kind"`       // Kind of activity (e.g., "comment"), stored in "kind" column
+	Message   string    `db:"message"`    // Human-readable text of the notification, stored in "message" column
+	Link      string    `db:"link"`       // URL the notification points to, stored in "link" column
+	IsRead    bool      `db:"is_read"`    // True once the user has seen the notification, stored in "is_read"
+	CreatedAt time.Time `db:"created_at"` // Timestamp of notification creation, mapped to "created_at"
+}
+
+// NotificationsPageData contains data for rendering the user's notifications page
+type NotificationsPageData struct {
+	User          *User          // Current logged-in user
+	Notifications []Notification // Notifications addressed to the user, newest first
+	Categories    []Category     // List of categories
+}
+
+// Mention represents a user being mentioned with "@username" in a post or comment
+type Mention struct {
+	UserID     int       `db:"user_id"`     // ID of the mentioned user, stored in "user_id"
+	AuthorID   int       `db:"author_id"`   // ID of the user who wrote the mention, stored in "author_id"
+	TargetType string    `db:"target_type"` // Where the mention appears: "post" or "comment", stored in "target_type"
+	TargetID   int       `db:"target_id"`   // ID of the post or comment, stored in "target_id"
+	PostID     int       `db:"post_id"`     // ID of the post (or the comment's post), stored in "post_id"
+	CreatedAt  time.Time `db:"created_at"`  // Timestamp of the mention, stored in "created_at"
+	PostTitle  string    // Title of the post, not mapped to the database
+	Author     string    // Username of the author, not mapped to the database
+	Body       string    // Text of the post or comment, not mapped to the database
+}
+
+// UserMentionsPageData contains data for rendering the "mentions of me" page
+type UserMentionsPageData struct {
+	User       *User      // Current logged-in user
+	Mentions   []Mention  // Posts and comments mentioning the user
+	Categories []Category // List of categories
+}
+
+// Conversation represents a private one-to-one or group conversation
+type Conversation struct {
+	ID          int       `db:"id"`         // Unique identifier for the conversation, corresponds to the "id" column
+	Title       string    `db:"title"`      // Optional title for group conversations, stored in "title" column
+	CreatedBy   int       `db:"created_by"` // ID of the user who started the conversation, stored in "created_by"
+	UpdatedAt   time.Time `db:"updated_at"` // Timestamp of the latest message, stored in "updated_at"
+	Members     string    // Comma-separated usernames of the other members, not mapped to the database
+	LastMessage string    // Text of the latest message, not mapped to the database
+	UnreadCount int       // Number of unread messages for the current user, not mapped to the database
+	Muted       bool      // Whether the current user muted the conversation, not mapped to the database
+}
+
+// Message represents a single private message within a conversation
+type Message struct {
+	ID             int       `db:"id"`              // Unique identifier for the message, corresponds to the "id" column
+	ConversationID int       `db:"conversation_id"` // ID of the conversation, stored in "conversation_id"
+	UserID         int       `db:"user_id"`         // ID of the sender, stored in "user_id"
+	Body           string    `db:"body"`            // Content of the message, stored in "body" column
+	CreatedAt      time.Time `db:"created_at"`      // Timestamp of the message, stored in "created_at"
+	Username       string    // Username of the sender, not mapped to the database
+}
+
+// InboxPageData contains data for rendering the private messages inbox
+type InboxPageData struct {
+	User          *User          // Current logged-in user
+	Conversations []Conversation // Conversations of the user, most recently active first
+	TotalUnread   int            // Unread messages across all conversations that are not muted
+	BlockedUsers  []User         // Users blocked by the current user
+	Categories    []Category     // List of categories
+	ErrorMessage  string         // Error message to display (if any)
+}
+
+// ConversationPageData contains data for rendering a single conversation
+type ConversationPageData struct {
+	User         *User        // Current logged-in user
+	Conversation Conversation // Conversation being displayed
+	Messages     []Message    // Messages in the conversation, oldest first
+	Categories   []Category   // List of categories
+	ErrorMessage string       // Error message to display (if any)
+}
+
+// BookmarkList represents a named reading list of bookmarked posts and comments
+type BookmarkList struct {
+	ID        int        `db:"id"`         // Unique identifier for the list, corresponds to the "id" column
+	UserID    int        `db:"user_id"`    // ID of the user who owns the list, stored in "user_id"
+	Name      string     `db:"name"`       // Name of the list (e.g., "To read"), stored in "name" column
+	IsPublic  bool       `db:"is_public"`  // Whether other users can see the list, stored in "is_public"
+	CreatedAt time.Time  `db:"created_at"` // Timestamp of list creation, mapped to "created_at"
+	Items     []Bookmark // Bookmarks in the list, not mapped to the database
+}
+
+// Bookmark represents a post or comment saved into a reading list
+type Bookmark struct {
+	ID         int       `db:"id"`          // Unique identifier for the bookmark, corresponds to the "id" column
+	ListID     int       `db:"list_id"`     // ID of the reading list, stored in "list_id"
+	TargetType string    `db:"target_type"` // Type of bookmarked content: "post" or "comment", stored in "target_type"
+	TargetID   int       `db:"target_id"`   // ID of the post or comment, stored in "target_id"
+	CreatedAt  time.Time `db:"created_at"`  // Timestamp of the bookmark, stored in "created_at"
+	PostID     int       // ID of the post (or the comment's post), not mapped to the database
+	Title      string    // Title of the post, not mapped to the database
+	Excerpt    string    // Beginning of the post or comment text, not mapped to the database
+}
+
+// BookmarksPageData contains data for rendering a user's reading lists
+type BookmarksPageData struct {
+	User         *User          // Current logged-in user
+	Owner        *User          // User whose lists are displayed
+	IsOwner      bool           // Whether the current user owns the lists
+	Lists        []BookmarkList // Reading lists with their bookmarks
+	Categories   []Category     // List of categories
+	ErrorMessage string         // Error message to display (if any)
+}
+
+// ProfileSettings holds the privacy settings that control what a public profile shows
+type ProfileSettings struct {
+	UserID       int    `db:"user_id"`       // ID of the profile owner, stored in "user_id"
+	Visibility   string `db:"visibility"`    // Who can see the profile: "public", "members" or "private"
+	ShowBio      bool   `db:"show_bio"`      // Whether the bio is shown, stored in "show_bio"
+	ShowActivity bool   `db:"show_activity"` // Whether recent posts and comments are shown, stored in "show_activity"
+	ShowLikes    bool   `db:"show_likes"`    // Whether received likes are shown, stored in "show_likes"
+	ShowFollows  bool   `db:"show_follows"`  // Whether follower counts are shown, stored in "show_follows"
+}
+
+// Activity represents a recent post or comment listed on a public profile
+type Activity struct {
+	Kind      string    // "post" or "comment"
+	PostID    int       // ID of the post (or the comment's post)
+	TargetID  int       // ID of the post or comment
+	Title     string    // Title of the post
+	Excerpt   string    // Beginning of the post or comment text
+	CreatedAt time.Time // Timestamp of the post or comment
+}
+
+// Badge represents a badge earned by a user
+type Badge struct {
+	Key         string    `db:"badge"` // Key of the badge rule, stored in "badge" column
+	Name        string    // Display name, not mapped to the database
+	Description string    // How the badge is earned, not mapped to the database
+	AwardedAt   time.Time `db:"awarded_at"` // Timestamp of when the badge was earned, stored in "awarded_at"
+}
+
+// ProfilePageData contains data for rendering a public user profile
+type ProfilePageData struct {
+	User           *User           // Current logged-in user
+	Profile        *User           // User whose profile is displayed
+	Settings       ProfileSettings // Privacy settings of the profile owner
+	IsOwner        bool            // Whether the current user owns the profile
+	TrustLevel     int             // Trust level of the profile owner
+	TrustName      string          // Label of the trust level, e.g. "Member"
+	Reputation     int             // Reputation earned from reactions received
+	Badges         []Badge         // Badges earned by the profile owner
+	Restricted     bool            // Whether the privacy settings hide the profile from the viewer
+	PostCount      int             // Number of posts written by the user
+	CommentCount   int             // Number of comments written by the user
+	LikesReceived  int             // Likes received on the user's posts and comments
+	FollowerCount  int             // Number of users following the user
+	FollowingCount int             // Number of users the user follows
+	IsFollowing    bool            // Whether the current user follows the profile owner
+	Activity       []Activity      // Recent posts and comments, newest first
+	Categories     []Category      // List of categories
+}
+
+// Report represents a member's report about a post, comment or user
+type Report struct {
+	ID            int       `db:"id"`          // Unique identifier for the report, corresponds to the "id" column
+	ReporterID    int       `db:"reporter_id"` // ID of the user who filed the report, stored in "reporter_id"
+	TargetType    string    `db:"target_type"` // Type of reported content: "post", "comment" or "user", stored in "target_type"
+	TargetID      int       `db:"target_id"`   // ID of the reported post, comment or user, stored in "target_id"
+	Reason        string    `db:"reason"`      // Reason category (e.g., "spam"), stored in "reason" column
+	Details       string    `db:"details"`     // Optional explanation from the reporter, stored in "details" column
+	Status        string    `db:"status"`      // Triage state: "open", "dismissed" or "actioned", stored in "status"
+	Weight        int       `db:"weight"`      // Weight of the report from the reporter's trust level, stored in "weight"
+	CreatedAt     time.Time `db:"created_at"`  // Timestamp of the report, stored in "created_at"
+	Reporter      string    // Username of the reporter, not mapped to the database
+	TargetSummary string    // Title or excerpt of the reported content, not mapped to the database
+	TargetLink    string    // Link to the reported content, not mapped to the database
+	OffenderID    int       // ID of the user responsible for the content, not mapped to the database
+	Offender      string    // Username of the user responsible for the content, not mapped to the database
+}
+
+// ModerationLogEntry represents one recorded moderator action; entries are never changed or deleted
+type ModerationLogEntry struct {
+	ID          int       `db:"id"`           // Unique identifier for the entry, corresponds to the "id" column
+	ModeratorID int       `db:"moderator_id"` // ID of the moderator, stored in "moderator_id"
+	Action      string    `db:"action"`       // Action taken (e.g., "warn"), stored in "action" column
+	TargetType  string    `db:"target_type"`  // Type of the affected content, stored in "target_type"
+	TargetID    int       `db:"target_id"`    // ID of the affected content, stored in "target_id"
+	ReportID    int       `db:"report_id"`    // ID of the related report or 0, stored in "report_id"
+	Details     string    `db:"details"`      // Moderator's note, stored in "details" column
+	CreatedAt   time.Time `db:"created_at"`   // Timestamp of the action, stored in "created_at"
+	Moderator   string    // Username of the moderator, not mapped to the database
+}
+
+// ModerationPageData contains data for rendering the moderator queue
+type ModerationPageData struct {
+	User          *User            // Current logged-in moderator
+	Reports       []Report         // Reports matching the selected status
+	Sanctions     []Sanction       // Suspensions, bans and shadowbans currently in force
+	Held          []HeldSubmission // Posts and comments waiting for review
+	Announcements []Announcement   // Site-wide announcements currently shown
+	Scheduled     []Post           // Posts waiting for their publishing time, listed for admins only
+	Status        string           // Status filter: "open" or "closed"
+	Categories    []Category       // List of categories
+	ErrorMessage  string           // Error message to display (if any)
+}
+
+// Announcement represents a site-wide message posted by a moderator
+type Announcement struct {
+	ID        int       `db:"id"`         // Unique identifier, corresponds to the "id" column
+	Message   string    `db:"message"`    // Text shown to every visitor, stored in "message" column
+	Link      string    `db:"link"`       // Optional URL, stored in "link" column
+	CreatedAt time.Time `db:"created_at"` // Timestamp of when it was posted, stored in "created_at"
+	CreatedBy string    // Username of the moderator who posted it, not mapped to the database
+}
+
+// ModerationLogPageData contains data for rendering the moderation log
+type ModerationLogPageData struct {
+	User       *User                // Current logged-in moderator
+	Entries    []ModerationLogEntry // Log entries, newest first
+	Categories []Category           // List of categories
+}
+
+// Sanction represents a suspension, ban or shadowban imposed on a user by a moderator
+type Sanction struct {
+	ID        int       `db:"id"`         // Unique identifier for the sanction, corresponds to the "id" column
+	UserID    int       `db:"user_id"`    // ID of the sanctioned user, stored in "user_id"
+	Kind      string    `db:"kind"`       // "suspension", "ban" or "shadowban", stored in "kind" column
+	Reason    string    `db:"reason"`     // Reason given by the moderator, stored in "reason" column
+	ExpiresAt time.Time `db:"expires_at"` // End of a suspension; zero if the sanction does not expire
+	CreatedAt time.Time `db:"created_at"` // Timestamp of the sanction, stored in "created_at"
+	Username  string    // Username of the sanctioned user, not mapped to the database
+	Moderator string    // Username of the moderator, not mapped to the database
+}
+
+// WordFilter represents an admin-managed blocked or flagged word or regular expression
+type WordFilter struct {
+	ID          int       `db:"id"`          // Unique identifier for the filter, corresponds to the "id" column
+	Pattern     string    `db:"pattern"`     // Word or regular expression, stored in "pattern" column
+	IsRegex     bool      `db:"is_regex"`    // Whether the pattern is a regular expression, stored in "is_regex"
+	Policy      string    `db:"policy"`      // "replace", "hold" or "reject", stored in "policy" column
+	Replacement string    `db:"replacement"` // Text that replaces matches, stored in "replacement" column
+	CreatedAt   time.Time `db:"created_at"`  // Timestamp of when the filter was added, stored in "created_at"
+	CreatedBy   string    // Username of the admin who added the filter, not mapped to the database
+}
+
+// HeldSubmission represents a post or comment waiting for moderator review
+type HeldSubmission struct {
+	ID         int       `db:"id"`          // Unique identifier, corresponds to the "id" column
+	UserID     int       `db:"user_id"`     // ID of the author, stored in "user_id" column
+	TargetType string    `db:"target_type"` // "post" or "comment", stored in "target_type" column
+	PostID     int       `db:"post_id"`     // Post a held comment belongs to, stored in "post_id" column
+	CategoryID int       `db:"category_id"` // Category of a held post, stored in "category_id" column
+	Title      string    `db:"title"`       // Title of a held post, stored in "title" column
+	Body       string    `db:"body"`        // Text of the submission, stored in "body" column
+	Reason     string    `db:"reason"`      // Why the submission was held, stored in "reason" column
+	Status     string    `db:"status"`      // "pending", "approved" or "rejected", stored in "status" column
+	CreatedAt  time.Time `db:"created_at"`  // Timestamp of the submission, stored in "created_at"
+	Username   string    // Username of the author, not mapped to the database
+	PostTitle  string    // Title of the post a held comment belongs to, not mapped to the database
+}
+
+// WordFiltersPageData contains data for rendering the word filter management page
+type WordFiltersPageData struct {
+	User         *User        // Current logged-in admin
+	Filters      []WordFilter // Configured filters, newest first
+	Categories   []Category   // List of categories
+	ErrorMessage string       // Error message to display (if any)
+}
+
+// LeaderboardEntry represents one ranked contributor on a precomputed leaderboard
+type LeaderboardEntry struct {
+	Rank     int    `db:"rank"`    // Position on the leaderboard, starting at 1, stored in "rank" column
+	UserID   int    `db:"user_id"` // ID of the contributor, stored in "user_id" column
+	Score    int    `db:"score"`   // Value of the ranked metric, stored in "score" column
+	Username string // Username of the contributor, not mapped to the database
+}
+
+// Leaderboard is one ranking shown on the leaderboard page
+type Leaderboard struct {
+	Title   string             // Heading, e.g. "Most posts"
+	Entries []LeaderboardEntry // Contributors, best first
+}
+
+// LeaderboardPeriod is a time window offered on the leaderboard page
+type LeaderboardPeriod struct {
+	Key   string // Value of the "period" query parameter
+	Label string // Link text, e.g. "This week"
+}
+
+// LeaderboardPageData contains data for rendering the leaderboard page
+type LeaderboardPageData struct {
+	User       *User               // Current logged-in user (nil for visitors)
+	Period     string              // Selected period key
+	Periods    []LeaderboardPeriod // Available periods
+	CategoryID int                 // Selected category, or 0 for all categories
+	Boards     []Leaderboard       // One leaderboard per metric
+	ComputedAt time.Time           // When the leaderboards were last computed
+	Categories []Category          // List of categories
+}
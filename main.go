@@ -2,6 +2,7 @@ package main
 
 // Import necessary packages
 import (
+	"flag"                                // For parsing command-line options
 	database "literary-lions/internal/db" // Custom package for database operations
 	"literary-lions/internal/handlers"    // Custom package for HTTP request handlers
 	"log"                                 // For logging server messages
@@ -15,6 +16,45 @@ func main() {
 	// Ensure the database connection is closed when the program terminates.
 	defer db.Close()
 
+	// Administrative commands run against the database and exit instead of starting the server.
+	// Example: go run . -set-role=alice -role=moderator
+	setRole := flag.String("set-role", "", "username whose role should be changed")
+	role := flag.String("role", "moderator", "role to assign with -set-role: member, moderator or admin")
+	trainSpam := flag.Bool("train-spam", false, "rebuild the spam classifier from the moderators' spam and ham decisions")
+	reconcile := flag.Bool("reconcile-counters", false, "rebuild the like, dislike and comment counters of posts and comments")
+	flag.Parse()
+
+	if *setRole != "" {
+		if *role != "member" && *role != "moderator" && *role != "admin" {
+			log.Fatalf("Unknown role %q", *role)
+		}
+		if err := database.SetUserRole(db, *setRole, *role); err != nil {
+			log.Fatalf("Error changing role: %v", err)
+		}
+		log.Printf("User %s is now %s", *setRole, *role)
+		return
+	}
+
+	if *trainSpam {
+		spamDocs, hamDocs, err := handlers.RetrainSpamClassifier(db)
+		if err != nil {
+			log.Fatalf("Error training spam classifier: %v", err)
+		}
+		log.Printf("Spam classifier trained on %d spam and %d ham examples", spamDocs, hamDocs)
+		return
+	}
+
+	if *reconcile {
+		if err := database.ReconcileCounters(db); err != nil {
+			log.Fatalf("Error reconciling counters: %v", err)
+		}
+		log.Println("Counters rebuilt from reactions and comments")
+		return
+	}
+
+	// Run the background jobs, such as trust levels, reputation and badges, now and then on their schedules.
+	handlers.StartScheduledJobs(db)
+
 	// Serve static files, such as CSS, JS, and images, from the "assets/static" directory.
 	// http.FileServer creates a handler to serve these files.
 	fs := http.FileServer(http.Dir("assets/static"))
@@ -44,6 +84,16 @@ func main() {
 		handlers.UserLikesHandler(w, r, db)
 	})
 
+	// Serve the posts and comments that mention the user.
+	http.HandleFunc("/user/mentions", func(w http.ResponseWriter, r *http.Request) {
+		handlers.UserMentionsHandler(w, r, db)
+	})
+
+	// Suggest usernames for @mention autocomplete.
+	http.HandleFunc("/users/search", func(w http.ResponseWriter, r *http.Request) {
+		handlers.UserSearchHandler(w, r, db)
+	})
+
 	// Allow the user to change their username.
 	http.HandleFunc("/user/change_username", func(w http.ResponseWriter, r *http.Request) {
 		handlers.HandleChangeUsername(w, r, db)
@@ -64,6 +114,16 @@ func main() {
 		handlers.ServeProfileImage(w, r, db)
 	})
 
+	// Serve public user profiles at "/u/{username}".
+	http.HandleFunc("/u/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.PublicProfileHandler(w, r, db)
+	})
+
+	// Save the privacy settings of the user's public profile.
+	http.HandleFunc("/user/privacy", func(w http.ResponseWriter, r *http.Request) {
+		handlers.PrivacySettingsHandler(w, r, db)
+	})
+
 	// Allow the user to add or update their bio.
 	http.HandleFunc("/user/add_bio", func(w http.ResponseWriter, r *http.Request) {
 		handlers.HandleChangeBio(w, r, db)
@@ -103,14 +163,32 @@ func main() {
 		handlers.CategoriesHandler(w, r, db)
 	})
 
-	// Handle requests to create a new comment on a post.
-	http.HandleFunc("/comment", func(w http.ResponseWriter, r *http.Request) {
-		handlers.CreateCommentHandler(w, r, db)
+	// Let trusted members create a new category.
+	http.HandleFunc("/categories/new", func(w http.ResponseWriter, r *http.Request) {
+		handlers.CreateCategoryHandler(w, r, db)
 	})
 
-	// Handle requests to create a new post.
-	http.HandleFunc("/new-post", func(w http.ResponseWriter, r *http.Request) {
+	// Switch a category's Q&A mode (moderators only).
+	http.HandleFunc("/categories/question", func(w http.ResponseWriter, r *http.Request) {
+		handlers.CategoryQuestionHandler(w, r, db)
+	})
+
+	// Handle requests to create a new comment on a post, rate limited per user and IP.
+	http.HandleFunc("/comment", handlers.RateLimited(db, "comment", func(w http.ResponseWriter, r *http.Request) {
+		handlers.CreateCommentHandler(w, r, db)
+	}))
+
+	// Handle requests to create a new post, rate limited per user and IP.
+	http.HandleFunc("/new-post", handlers.RateLimited(db, "post", func(w http.ResponseWriter, r *http.Request) {
 		handlers.NewPostHandler(w, r, db)
+	}))
+
+	// Save the new post form as a draft (also used by autosave), and throw a draft away.
+	http.HandleFunc("/drafts/save", func(w http.ResponseWriter, r *http.Request) {
+		handlers.SaveDraftHandler(w, r, db)
+	})
+	http.HandleFunc("/drafts/delete", func(w http.ResponseWriter, r *http.Request) {
+		handlers.DeleteDraftHandler(w, r, db)
 	})
 
 	// Handle search queries.
@@ -118,16 +196,184 @@ func main() {
 		handlers.SearchHandler(w, r, db)
 	})
 
-	// Define routes for liking/disliking posts or comments.
+	// Define the route for reacting to posts or comments.
+
+	// Give or take back a reaction to a post or comment, rate limited per user and IP.
+	http.HandleFunc("/react", handlers.RateLimited(db, "reaction", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ReactHandler(w, r, db)
+	}))
+
+	// Mark a post as a question, and accept a comment as its answer.
+	http.HandleFunc("/post/question", func(w http.ResponseWriter, r *http.Request) {
+		handlers.MarkQuestionHandler(w, r, db)
+	})
+	http.HandleFunc("/post/accept", func(w http.ResponseWriter, r *http.Request) {
+		handlers.AcceptAnswerHandler(w, r, db)
+	})
+
+	// Vote in a poll attached to a post, rate limited like reactions.
+	http.HandleFunc("/poll/vote", handlers.RateLimited(db, "reaction", func(w http.ResponseWriter, r *http.Request) {
+		handlers.PollVoteHandler(w, r, db)
+	}))
+
+	// Define routes for real-time updates and notifications.
+
+	// Stream new comments and reaction counts for a single post over Server-Sent Events.
+	http.HandleFunc("/events/post/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.PostEventsHandler(w, r, db)
+	})
+
+	// Stream notifications for the logged-in user over Server-Sent Events.
+	http.HandleFunc("/events/user", func(w http.ResponseWriter, r *http.Request) {
+		handlers.UserEventsHandler(w, r, db)
+	})
+
+	// Serve the user's notifications page.
+	http.HandleFunc("/notifications", func(w http.ResponseWriter, r *http.Request) {
+		handlers.NotificationsHandler(w, r, db)
+	})
+
+	// Mark all of the user's notifications as read.
+	http.HandleFunc("/notifications/read", func(w http.ResponseWriter, r *http.Request) {
+		handlers.MarkNotificationsReadHandler(w, r, db)
+	})
+
+	// Define routes for following users and categories.
+
+	// Serve the personalized feed of followed authors and categories.
+	http.HandleFunc("/feed", func(w http.ResponseWriter, r *http.Request) {
+		handlers.FeedHandler(w, r, db)
+	})
+
+	// Follow or unfollow a user.
+	http.HandleFunc("/follow/user", func(w http.ResponseWriter, r *http.Request) {
+		handlers.FollowUserHandler(w, r, db)
+	})
+
+	// Follow or unfollow a category.
+	http.HandleFunc("/follow/category", func(w http.ResponseWriter, r *http.Request) {
+		handlers.FollowCategoryHandler(w, r, db)
+	})
+
+	// Serve the user's reading lists, or another user's public lists with "?user_id=".
+	http.HandleFunc("/user/bookmarks", func(w http.ResponseWriter, r *http.Request) {
+		handlers.UserBookmarksHandler(w, r, db)
+	})
 
-	// Handle likes/dislikes for posts.
-	http.HandleFunc("/like_dislike", func(w http.ResponseWriter, r *http.Request) {
-		handlers.LikeDislikeHandler(w, r, db)
+	// Bookmark a post or comment into a reading list.
+	http.HandleFunc("/bookmarks/add", func(w http.ResponseWriter, r *http.Request) {
+		handlers.AddBookmarkHandler(w, r, db)
+	})
+
+	// Remove a bookmark from a reading list.
+	http.HandleFunc("/bookmarks/remove", func(w http.ResponseWriter, r *http.Request) {
+		handlers.RemoveBookmarkHandler(w, r, db)
+	})
+
+	// Create, publish/unpublish or delete a reading list.
+	http.HandleFunc("/bookmarks/lists", func(w http.ResponseWriter, r *http.Request) {
+		handlers.BookmarkListHandler(w, r, db)
+	})
+
+	// Define routes for private messaging.
+
+	// Serve the user's inbox of private conversations.
+	http.HandleFunc("/messages", func(w http.ResponseWriter, r *http.Request) {
+		handlers.InboxHandler(w, r, db)
+	})
+
+	// Start a new one-to-one or group conversation.
+	http.HandleFunc("/messages/new", func(w http.ResponseWriter, r *http.Request) {
+		handlers.NewConversationHandler(w, r, db)
+	})
+
+	// Read, reply to or mute a single conversation.
+	http.HandleFunc("/messages/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ConversationHandler(w, r, db)
+	})
+
+	// Block a user from messaging the current user.
+	http.HandleFunc("/user/block", func(w http.ResponseWriter, r *http.Request) {
+		handlers.BlockUserHandler(w, r, db)
+	})
+
+	// Remove a block.
+	http.HandleFunc("/user/unblock", func(w http.ResponseWriter, r *http.Request) {
+		handlers.UnblockUserHandler(w, r, db)
+	})
+
+	// Report a post, comment or user to the moderators.
+	http.HandleFunc("/report", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ReportHandler(w, r, db)
+	})
+
+	// Serve the moderator queue of open and handled reports.
+	http.HandleFunc("/moderation", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ModerationQueueHandler(w, r, db)
+	})
+
+	// Dismiss a report, delete the content, or warn, suspend, ban or shadowban its author.
+	http.HandleFunc("/moderation/action", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ModerationActionHandler(w, r, db)
+	})
+
+	// Suspend, ban or shadowban a user directly.
+	http.HandleFunc("/moderation/sanction", func(w http.ResponseWriter, r *http.Request) {
+		handlers.SanctionUserHandler(w, r, db)
+	})
+
+	// Lift a suspension, ban or shadowban early.
+	http.HandleFunc("/moderation/sanction/lift", func(w http.ResponseWriter, r *http.Request) {
+		handlers.LiftSanctionHandler(w, r, db)
+	})
+
+	// Approve or reject a post or comment held by the word filters or spam heuristics.
+	http.HandleFunc("/moderation/held", func(w http.ResponseWriter, r *http.Request) {
+		handlers.HeldSubmissionHandler(w, r, db)
+	})
+
+	// Let admins manage the blocked and flagged word filters.
+	http.HandleFunc("/moderation/filters", func(w http.ResponseWriter, r *http.Request) {
+		handlers.WordFiltersHandler(w, r, db)
+	})
+
+	// Serve the append-only log of moderator actions.
+	http.HandleFunc("/moderation/log", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ModerationLogHandler(w, r, db)
+	})
+
+	// Pin, unpin, lock or unlock a thread (moderators only).
+	http.HandleFunc("/moderation/thread", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ThreadHandler(w, r, db)
+	})
+
+	// Move a thread to another category, merge it into another thread or split comments off it (moderators only).
+	http.HandleFunc("/moderation/thread/move", func(w http.ResponseWriter, r *http.Request) {
+		handlers.MoveThreadHandler(w, r, db)
+	})
+	http.HandleFunc("/moderation/thread/merge", func(w http.ResponseWriter, r *http.Request) {
+		handlers.MergeThreadHandler(w, r, db)
+	})
+	http.HandleFunc("/moderation/thread/split", func(w http.ResponseWriter, r *http.Request) {
+		handlers.SplitThreadHandler(w, r, db)
+	})
+
+	// Post or end a site-wide announcement (moderators only).
+	http.HandleFunc("/moderation/announcements", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ManageAnnouncementsHandler(w, r, db)
+	})
+
+	// Running announcements for the header, and dismissing one of them.
+	http.HandleFunc("/announcements", func(w http.ResponseWriter, r *http.Request) {
+		handlers.AnnouncementsHandler(w, r, db)
+	})
+	http.HandleFunc("/announcements/dismiss", func(w http.ResponseWriter, r *http.Request) {
+		handlers.DismissAnnouncementHandler(w, r, db)
 	})
 
-	// Handle likes for individual comments.
-	http.HandleFunc("/comment_like/", func(w http.ResponseWriter, r *http.Request) {
-		handlers.CommentLikeHandler(w, r, db)
+	// Serve the precomputed leaderboards of top contributors.
+	http.HandleFunc("/leaderboard", func(w http.ResponseWriter, r *http.Request) {
+		handlers.LeaderboardHandler(w, r, db)
 	})
 
 	// Start the HTTP server on port 8080.